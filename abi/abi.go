@@ -1,6 +1,7 @@
 package abi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"golang.org/x/crypto/sha3"
@@ -115,10 +116,43 @@ type Interface struct {
 
 type Method struct {
 	Name          string
+	Signature     string
 	Sig           []byte
+	InputNames    []string
+	InputTypes    []string
 	InputEncoder  *InputEncoder
 	OutputDecoder *OutputDecoder
 	IsConstant    bool
+
+	inputDecoder decoder
+}
+
+// DecodeInput decodes the arguments of calldata, the selector included
+func (m *Method) DecodeInput(data []byte) (args []any, err error) {
+	if len(data) < 4 || !bytes.Equal(data[:4], m.Sig) {
+		return nil, fmt.Errorf("calldata is not a call of %s", m.Signature)
+	}
+	defer func() {
+		if recover() != nil {
+			err = fmt.Errorf("calldata of %s is truncated", m.Signature)
+		}
+	}()
+	v, err := m.inputDecoder.Decode(newDecodeContext(data[4:]))
+	if err != nil {
+		return nil, err
+	}
+	args, _ = v.([]any)
+	return args, nil
+}
+
+// MethodBySig finds the method of a 4 bytes selector
+func (i *Interface) MethodBySig(sig []byte) *Method {
+	for k := range i.Methods {
+		if bytes.Equal(i.Methods[k].Sig, sig) {
+			return &i.Methods[k]
+		}
+	}
+	return nil
 }
 
 type EventInput struct {
@@ -198,14 +232,31 @@ func parseFunction(r *record) (Method, error) {
 	if err != nil {
 		return Method{}, err
 	}
+	// the arguments are a tuple laid out in place
+	inputDecoder := &tupleDecoder{}
+	for _, t := range inputTypes {
+		d, err := createDecoder(t)
+		if err != nil {
+			return Method{}, err
+		}
+		inputDecoder.subDecoders = append(inputDecoder.subDecoders, d)
+	}
+	var inputNames []string
+	for _, input := range r.Inputs {
+		inputNames = append(inputNames, input.Name)
+	}
 	isConstant := r.StateMutability == "pure" || r.StateMutability == "view"
 
 	return Method{
 		Name:          r.Name,
+		Signature:     funcName,
 		Sig:           calcFunctionSig(funcName),
+		InputNames:    inputNames,
+		InputTypes:    inputTypes,
 		InputEncoder:  encoder,
 		OutputDecoder: decoder,
 		IsConstant:    isConstant,
+		inputDecoder:  inputDecoder,
 	}, nil
 }
 
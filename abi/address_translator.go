@@ -1,6 +1,9 @@
 package abi
 
-import "encoding/hex"
+import (
+	"encoding/hex"
+	"strings"
+)
 
 type AddressTranslator interface {
 	FromEthAddress(addr []byte) (any, error)
@@ -9,6 +12,13 @@ type AddressTranslator interface {
 
 var CustomAddressTranslator AddressTranslator
 
+// TypedDataAddressTranslator is an AddressTranslator which also parses the
+// address strings of typed data, looser than ToEthAddress
+type TypedDataAddressTranslator interface {
+	AddressTranslator
+	TypedDataAddress(s string) (any, error)
+}
+
 type defaultAddressTranslator struct{}
 
 func (d defaultAddressTranslator) FromEthAddress(addr []byte) (any, error) {
@@ -37,6 +47,19 @@ func decodeAddress(addr []byte) (any, error) {
 	return CustomAddressTranslator.FromEthAddress(addr)
 }
 
+// typedDataAddress converts an address string of typed data to a value for
+// encodeAddress, with the TypedDataAddressTranslator when there is one
+func typedDataAddress(addr any) (any, error) {
+	s, ok := addr.(string)
+	if !ok {
+		return addr, nil
+	}
+	if t, ok := CustomAddressTranslator.(TypedDataAddressTranslator); ok {
+		return t.TypedDataAddress(s)
+	}
+	return strings.TrimPrefix(s, "0x"), nil
+}
+
 func encodeAddress(addr any) ([]byte, error) {
 	if CustomAddressTranslator == nil {
 		return defaultAddressTranslator{}.ToEthAddress(addr)
@@ -27,20 +27,10 @@ func (ctx *decodeContext) ReadBool() (bool, error) {
 }
 
 func (ctx *decodeContext) ReadBigInt(hasSign bool) (*big.Int, error) {
-	neg := false
-	if hasSign {
-		if ctx.data[ctx.offset] > 128 {
-			neg = true
-			data := ctx.data[ctx.offset : ctx.offset+32]
-			for i, _ := range data {
-				data[i] = ^data[i]
-			}
-			data[31] = data[31] + 1
-		}
-	}
-	i := new(big.Int).SetBytes(ctx.data[ctx.offset : ctx.offset+32])
-	if neg {
-		i = i.Neg(i)
+	word := ctx.data[ctx.offset : ctx.offset+32]
+	i := new(big.Int).SetBytes(word)
+	if hasSign && word[0]&0x80 != 0 {
+		i.Sub(i, new(big.Int).Lsh(big.NewInt(1), 256))
 	}
 	ctx.GoNext(32)
 	return i, nil
@@ -49,6 +39,7 @@ func (ctx *decodeContext) ReadBigInt(hasSign bool) (*big.Int, error) {
 func (ctx *decodeContext) ReadAddress() ([]byte, error) {
 	addr := make([]byte, 20)
 	copy(addr, ctx.data[ctx.offset+12:ctx.offset+32])
+	ctx.GoNext(32)
 	return addr, nil
 }
 
@@ -121,18 +112,23 @@ func getDecodeContext(ctx *decodeContext, isDyn bool, size int) (*decodeContext,
 	}
 	if size < 0 {
 		size = cc.ReadLen()
+		// offsets inside a dynamic array start after its length
+		cc = newDecodeContext(cc.RemainingBytes())
 	}
-	return ctx, size
+	return cc, size
 }
 
 func decodeBytes(ctx *decodeContext, size int) ([]byte, error) {
 	var cc *decodeContext
 	isDyn := size < 0
 	cc, size = getDecodeContext(ctx, isDyn, size)
+	if len(cc.RemainingBytes()) < size {
+		return nil, fmt.Errorf("bytes out of range")
+	}
 	buf := make([]byte, size)
 	copy(buf, cc.RemainingBytes())
 	if !isDyn {
-		cc.GoNext(size + 32 - (size % 32))
+		cc.GoNext(32)
 	}
 	return buf, nil
 }
@@ -235,8 +231,8 @@ func createDecoder(t string) (decoder, error) {
 	if err != nil {
 		return nil, err
 	}
-	if size > 0 {
-		if len(types) == 1 {
+	if size != 0 {
+		if strings.HasSuffix(t, "]") {
 			subDecoder, err := createDecoder(types[0])
 			if err != nil {
 				return nil, err
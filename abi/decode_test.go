@@ -0,0 +1,203 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func words(t *testing.T, ws ...string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(strings.Join(ws, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func word(v string) string {
+	return strings.Repeat("0", 64-len(v)) + v
+}
+
+// rawAddresses decodes addresses as 20 bytes during the test, the address
+// package installs its translator when it is linked in the test binary
+func rawAddresses(t *testing.T) {
+	saved := CustomAddressTranslator
+	CustomAddressTranslator = nil
+	t.Cleanup(func() { CustomAddressTranslator = saved })
+}
+
+func TestDecodeSpecExample(t *testing.T) {
+	// f(uint256,uint32[],bytes10,bytes) of the Solidity ABI specification
+	data := words(t,
+		word("123"),
+		word("80"),
+		"3132333435363738393000000000000000000000000000000000000000000000",
+		word("e0"),
+		word("2"),
+		word("456"),
+		word("789"),
+		word("d"),
+		"48656c6c6f2c20776f726c642100000000000000000000000000000000000000",
+	)
+	types := []string{"uint256", "uint32[]", "bytes10", "bytes"}
+	got, err := DecodeTypedData(types, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{
+		big.NewInt(0x123),
+		[]any{big.NewInt(0x456), big.NewInt(0x789)},
+		[]byte("1234567890"),
+		[]byte("Hello, world!"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	enc, err := EncodeTypedData(types, []any{
+		big.NewInt(0x123),
+		[]any{big.NewInt(0x456), big.NewInt(0x789)},
+		[]byte("1234567890"),
+		[]byte("Hello, world!"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(enc, data) {
+		t.Fatalf("encoded %x", enc)
+	}
+}
+
+func TestDecodeDynamicArrayAfterBytes(t *testing.T) {
+	// sam(bytes,bool,uint256[]) of the Solidity ABI specification
+	data := words(t,
+		word("60"),
+		word("1"),
+		word("a0"),
+		word("4"),
+		"6461766500000000000000000000000000000000000000000000000000000000",
+		word("3"),
+		word("1"),
+		word("2"),
+		word("3"),
+	)
+	got, err := DecodeTypedData([]string{"bytes", "bool", "uint256[]"}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{[]byte("dave"), true, []any{big.NewInt(1), big.NewInt(2), big.NewInt(3)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeFixedArrays(t *testing.T) {
+	rawAddresses(t)
+	// (uint256[2], address, bytes32[1]) are all inline
+	addr := "00000000000000000000000011223344556677889900aabbccddeeff00112233"
+	data := words(t,
+		word("1"),
+		word("2"),
+		addr,
+		"ff00000000000000000000000000000000000000000000000000000000000001",
+	)
+	got, err := DecodeTypedData([]string{"uint256[2]", "address", "bytes32[1]"}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{
+		[]any{big.NewInt(1), big.NewInt(2)},
+		words(t, "11223344556677889900aabbccddeeff00112233"),
+		[]any{words(t, "ff00000000000000000000000000000000000000000000000000000000000001")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeAddresses(t *testing.T) {
+	rawAddresses(t)
+	// consecutive addresses, the second one is read after the first word
+	data := words(t,
+		"0000000000000000000000001111111111111111111111111111111111111111",
+		"0000000000000000000000002222222222222222222222222222222222222222",
+		word("60"),
+		word("1"),
+		"0000000000000000000000003333333333333333333333333333333333333333",
+	)
+	got, err := DecodeTypedData([]string{"address", "address", "address[]"}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{
+		bytes.Repeat([]byte{0x11}, 20),
+		bytes.Repeat([]byte{0x22}, 20),
+		[]any{bytes.Repeat([]byte{0x33}, 20)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestDecodeSignedInt(t *testing.T) {
+	data := words(t,
+		strings.Repeat("f", 64),
+		"8000000000000000000000000000000000000000000000000000000000000000",
+		word("7f"),
+	)
+	orig := append([]byte(nil), data...)
+	got, err := DecodeTypedData([]string{"int256", "int256", "int8"}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	min := new(big.Int).Neg(new(big.Int).Lsh(big.NewInt(1), 255))
+	want := []any{big.NewInt(-1), min, big.NewInt(127)}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if !bytes.Equal(data, orig) {
+		t.Fatal("decoding modified the data")
+	}
+}
+
+func TestDecodeTruncatedBytes(t *testing.T) {
+	data := words(t, word("20"), word("40"), "6461766500000000000000000000000000000000000000000000000000000000")
+	if _, err := DecodeTypedData([]string{"bytes"}, data); err == nil {
+		t.Fatal("decoded bytes longer than the data")
+	}
+}
+
+func TestMethodDecodeInput(t *testing.T) {
+	rawAddresses(t)
+	iface, err := Parse([]byte(`[{"type": "function", "name": "batch", "inputs": [
+		{"name": "to", "type": "address[]"},
+		{"name": "amounts", "type": "uint256[2]"}
+	]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := iface.MethodBySig(calcFunctionSig("batch(address[],uint256[2])"))
+	if m == nil {
+		t.Fatal("method not found")
+	}
+	to := []any{bytes.Repeat([]byte{0x11}, 20), bytes.Repeat([]byte{0x22}, 20)}
+	data, err := EncodeTypedData(m.InputTypes, []any{to, []any{big.NewInt(5), big.NewInt(6)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := m.DecodeInput(append(calcFunctionSig("batch(address[],uint256[2])"), data...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{to, []any{big.NewInt(5), big.NewInt(6)}}
+	if !reflect.DeepEqual(args, want) {
+		t.Fatalf("got %v, want %v", args, want)
+	}
+	if _, err := m.DecodeInput(append(calcFunctionSig("batch(address[],uint256[2])"), data[:40]...)); err == nil {
+		t.Fatal("decoded truncated calldata")
+	}
+}
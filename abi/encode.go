@@ -213,7 +213,7 @@ func (e *tupleEncoder) IsDynamic() bool {
 }
 
 func (e *tupleEncoder) Encode(ctx *encodeContext, val any) error {
-	return encodeDynamic(ctx, val, e.IsDynamic(), e.subEncoders)
+	return encodeDynamic(ctx, val, e.IsDynamic(), false, e.subEncoders)
 }
 
 type arrayEncoder struct {
@@ -226,10 +226,13 @@ func (e *arrayEncoder) IsDynamic() bool {
 }
 
 func (e *arrayEncoder) Encode(ctx *encodeContext, val any) error {
-	return encodeDynamic(ctx, val, e.IsDynamic(), []encoder{e.subEncoder})
+	return encodeDynamic(ctx, val, e.IsDynamic(), e.size < 0, []encoder{e.subEncoder})
 }
 
-func encodeDynamic(ctx *encodeContext, val any, isDyn bool, encoders []encoder) error {
+// encodeDynamic encodes the elements of val, behind an offset when isDyn.
+// Only the arrays of dynamic size have a length, not the tuples nor the
+// fixed size arrays of dynamic elements
+func encodeDynamic(ctx *encodeContext, val any, isDyn, withLen bool, encoders []encoder) error {
 	v := reflect.ValueOf(val)
 	if v.Kind() != reflect.Slice {
 		return ErrValueTypeNotSupport
@@ -237,7 +240,9 @@ func encodeDynamic(ctx *encodeContext, val any, isDyn bool, encoders []encoder)
 	cc := ctx
 	if isDyn {
 		ctx.AddDynamicRef()
-		ctx.WriteBigInt(big.NewInt(int64(v.Len())), false, true)
+		if withLen {
+			ctx.WriteBigInt(big.NewInt(int64(v.Len())), false, true)
+		}
 		cc = newEncodeContext()
 	}
 	getEncoder := func(idx int) encoder {
@@ -348,8 +353,8 @@ func createEncoder(t string) (encoder, error) {
 	if err != nil {
 		return nil, err
 	}
-	if size > 0 {
-		if len(types) == 1 {
+	if size != 0 {
+		if strings.HasSuffix(t, "]") {
 			subEncoder, err := createEncoder(types[0])
 			if err != nil {
 				return nil, err
@@ -0,0 +1,111 @@
+package abi
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// roundTrip checks that vals encode to want and decode back to vals
+func roundTrip(t *testing.T, types []string, vals []any, want []byte) {
+	t.Helper()
+	enc, err := EncodeTypedData(types, vals)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(enc, want) {
+		t.Fatalf("%v: encoded\n%x\nwant\n%x", types, enc, want)
+	}
+	got, err := DecodeTypedData(types, enc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, vals) {
+		t.Fatalf("%v: decoded %v, want %v", types, got, vals)
+	}
+}
+
+func TestEncodeNestedDynamicArrays(t *testing.T) {
+	// g(uint256[][],string[]) of the Solidity ABI specification, the
+	// offsets of the elements are relative to the start of each array
+	want := words(t,
+		word("40"),
+		word("140"),
+		word("2"),
+		word("40"),
+		word("a0"),
+		word("2"),
+		word("1"),
+		word("2"),
+		word("1"),
+		word("3"),
+		word("3"),
+		word("60"),
+		word("a0"),
+		word("e0"),
+		word("3"),
+		"6f6e650000000000000000000000000000000000000000000000000000000000",
+		word("3"),
+		"74776f0000000000000000000000000000000000000000000000000000000000",
+		word("5"),
+		"7468726565000000000000000000000000000000000000000000000000000000",
+	)
+	roundTrip(t, []string{"uint256[][]", "string[]"}, []any{
+		[]any{
+			[]any{big.NewInt(1), big.NewInt(2)},
+			[]any{big.NewInt(3)},
+		},
+		[]any{"one", "two", "three"},
+	}, want)
+}
+
+func TestEncodeFixedArrayOfDynamic(t *testing.T) {
+	// a fixed size array of dynamic elements is dynamic but has no length
+	want := words(t,
+		word("20"),
+		word("40"),
+		word("80"),
+		word("1"),
+		"6100000000000000000000000000000000000000000000000000000000000000",
+		word("1"),
+		"6200000000000000000000000000000000000000000000000000000000000000",
+	)
+	roundTrip(t, []string{"string[2]"}, []any{[]any{"a", "b"}}, want)
+}
+
+func TestEncodeStaticArrayInPlace(t *testing.T) {
+	// a fixed size array of static elements is laid out in place
+	want := words(t, word("5"), word("6"), word("7"))
+	roundTrip(t, []string{"uint256[2]", "uint8"}, []any{
+		[]any{big.NewInt(5), big.NewInt(6)},
+		big.NewInt(7),
+	}, want)
+}
+
+func TestEncodeTuples(t *testing.T) {
+	// a static tuple is laid out in place
+	roundTrip(t, []string{"(uint256,bool)", "uint8"}, []any{
+		[]any{big.NewInt(7), true},
+		big.NewInt(1),
+	}, words(t, word("7"), word("1"), word("1")))
+
+	// a dynamic tuple is behind an offset, without a length
+	roundTrip(t, []string{"(uint256,string)"}, []any{
+		[]any{big.NewInt(7), "ab"},
+	}, words(t,
+		word("20"),
+		word("7"),
+		word("40"),
+		word("2"),
+		"6162000000000000000000000000000000000000000000000000000000000000",
+	))
+
+	// an array of tuples is an array, not a tuple of its elements
+	roundTrip(t, []string{"(uint256,bool)[]"}, []any{
+		[]any{
+			[]any{big.NewInt(1), true},
+			[]any{big.NewInt(2), false},
+		},
+	}, words(t, word("20"), word("2"), word("1"), word("1"), word("2"), word("0")))
+}
@@ -0,0 +1,284 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const domainType = "EIP712Domain"
+
+// TypedDataField is a member of a struct type
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is TIP-712 structured data, the TRON flavour of EIP-712. Values
+// are given like in the json of TronWeb: numbers as int, *big.Int, json
+// numbers or decimal and 0x hex strings, bytes as []byte or hex strings,
+// addresses as anything the AddressTranslator takes, and as base58, 41 hex
+// or 0x hex of 20 bytes with the address package. trcToken is hashed as
+// uint256.
+//
+// If Types has no EIP712Domain it is derived from the fields of Domain
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]any              `json:"domain"`
+	Message     map[string]any              `json:"message"`
+}
+
+// ParseTypedData parses the json typed data of eth_signTypedData_v4 and
+// TronWeb _signTypedData
+func ParseTypedData(data []byte) (*TypedData, error) {
+	td := new(TypedData)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(td); err != nil {
+		return nil, err
+	}
+	return td, nil
+}
+
+// Hash returns the digest to sign, keccak256(0x1901 || domainSeparator || hashStruct(message))
+func (td *TypedData) Hash() ([]byte, error) {
+	domainSeparator, err := td.DomainSeparator()
+	if err != nil {
+		return nil, err
+	}
+	messageHash, err := td.HashStruct(td.PrimaryType, td.Message)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Write([]byte{0x19, 0x01})
+	buf.Write(domainSeparator)
+	buf.Write(messageHash)
+	return GetKeccak256Hash(buf.Bytes()), nil
+}
+
+// DomainSeparator returns hashStruct(domain)
+func (td *TypedData) DomainSeparator() ([]byte, error) {
+	return td.HashStruct(domainType, td.Domain)
+}
+
+func (td *TypedData) fields(typeName string) ([]TypedDataField, bool) {
+	if fields, ok := td.Types[typeName]; ok {
+		return fields, true
+	}
+	if typeName != domainType {
+		return nil, false
+	}
+	var fields []TypedDataField
+	for _, f := range []TypedDataField{
+		{Name: "name", Type: "string"},
+		{Name: "version", Type: "string"},
+		{Name: "chainId", Type: "uint256"},
+		{Name: "verifyingContract", Type: "address"},
+		{Name: "salt", Type: "bytes32"},
+	} {
+		if _, ok := td.Domain[f.Name]; ok {
+			fields = append(fields, f)
+		}
+	}
+	return fields, true
+}
+
+// EncodeType returns the type string of typeName, like
+// Mail(Person from,Person to,string contents)Person(string name,address wallet)
+func (td *TypedData) EncodeType(typeName string) (string, error) {
+	deps := map[string]bool{}
+	if err := td.collectDeps(typeName, deps); err != nil {
+		return "", err
+	}
+	delete(deps, typeName)
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf strings.Builder
+	for _, name := range append([]string{typeName}, names...) {
+		fields, _ := td.fields(name)
+		buf.WriteString(name)
+		buf.WriteByte('(')
+		for i, f := range fields {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(f.Type)
+			buf.WriteByte(' ')
+			buf.WriteString(f.Name)
+		}
+		buf.WriteByte(')')
+	}
+	return buf.String(), nil
+}
+
+func (td *TypedData) collectDeps(typeName string, deps map[string]bool) error {
+	if deps[typeName] {
+		return nil
+	}
+	fields, ok := td.fields(typeName)
+	if !ok {
+		return fmt.Errorf("%w: unknown struct %s", ErrTypeError, typeName)
+	}
+	deps[typeName] = true
+	for _, f := range fields {
+		base := baseType(f.Type)
+		if _, ok := td.Types[base]; ok {
+			if err := td.collectDeps(base, deps); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// TypeHash returns keccak256(encodeType(typeName))
+func (td *TypedData) TypeHash(typeName string) ([]byte, error) {
+	t, err := td.EncodeType(typeName)
+	if err != nil {
+		return nil, err
+	}
+	return GetKeccak256Hash([]byte(t)), nil
+}
+
+// HashStruct returns keccak256(typeHash || encodeData(data))
+func (td *TypedData) HashStruct(typeName string, data map[string]any) ([]byte, error) {
+	typeHash, err := td.TypeHash(typeName)
+	if err != nil {
+		return nil, err
+	}
+	fields, _ := td.fields(typeName)
+	var buf bytes.Buffer
+	buf.Write(typeHash)
+	for _, f := range fields {
+		enc, err := td.encodeValue(f.Type, data[f.Name])
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", typeName, f.Name, err)
+		}
+		buf.Write(enc)
+	}
+	return GetKeccak256Hash(buf.Bytes()), nil
+}
+
+// encodeValue returns the 32 byte encoding of a member
+func (td *TypedData) encodeValue(typ string, val any) ([]byte, error) {
+	if val == nil {
+		return nil, fmt.Errorf("missing value")
+	}
+	if strings.HasSuffix(typ, "]") {
+		v := reflect.ValueOf(val)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, ErrValueTypeNotSupport
+		}
+		elemType := typ[:strings.LastIndex(typ, "[")]
+		var buf bytes.Buffer
+		for i := 0; i < v.Len(); i++ {
+			enc, err := td.encodeValue(elemType, v.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(enc)
+		}
+		return GetKeccak256Hash(buf.Bytes()), nil
+	}
+	if _, ok := td.Types[typ]; ok {
+		m, ok := val.(map[string]any)
+		if !ok {
+			return nil, ErrValueTypeNotSupport
+		}
+		return td.HashStruct(typ, m)
+	}
+
+	switch typ {
+	case "string":
+		s, ok := val.(string)
+		if !ok {
+			return nil, ErrValueTypeNotSupport
+		}
+		return GetKeccak256Hash([]byte(s)), nil
+	case "bytes":
+		b, err := toBytes(val)
+		if err != nil {
+			return nil, err
+		}
+		return GetKeccak256Hash(b), nil
+	case "trcToken":
+		typ = "uint256"
+	}
+
+	e, err := createBasicEncoder(typ)
+	if err != nil {
+		return nil, err
+	}
+	switch e.(type) {
+	case *numEncoder:
+		val, err = toBigInt(val)
+	case *bytesEncoder:
+		val, err = toBytes(val)
+	case *addressEncoder:
+		val, err = typedDataAddress(val)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ctx := newEncodeContext()
+	if err := e.Encode(ctx, val); err != nil {
+		return nil, err
+	}
+	return ctx.Result(), nil
+}
+
+// baseType strips the array suffixes of a type
+func baseType(typ string) string {
+	if i := strings.Index(typ, "["); i >= 0 {
+		return typ[:i]
+	}
+	return typ
+}
+
+func toBigInt(val any) (*big.Int, error) {
+	switch v := val.(type) {
+	case *big.Int:
+		return v, nil
+	case int:
+		return big.NewInt(int64(v)), nil
+	case int64:
+		return big.NewInt(v), nil
+	case uint64:
+		return new(big.Int).SetUint64(v), nil
+	case float64:
+		if v != float64(int64(v)) {
+			return nil, fmt.Errorf("%v is not an integer", v)
+		}
+		return big.NewInt(int64(v)), nil
+	case json.Number:
+		return toBigInt(string(v))
+	case string:
+		i, ok := new(big.Int).SetString(v, 0)
+		if !ok {
+			return nil, fmt.Errorf("invalid integer %q", v)
+		}
+		return i, nil
+	}
+	return nil, ErrValueTypeNotSupport
+}
+
+func toBytes(val any) ([]byte, error) {
+	switch v := val.(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return hex.DecodeString(strings.TrimPrefix(v, "0x"))
+	}
+	return nil, ErrValueTypeNotSupport
+}
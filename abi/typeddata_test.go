@@ -0,0 +1,123 @@
+package abi_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/fullstackwang/tron-grpc/abi"
+	"github.com/fullstackwang/tron-grpc/address"
+)
+
+// mail is the example of the EIP-712 specification, with the addresses
+// replaced by %s
+const mail = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {"name": "Ether Mail", "version": "1", "chainId": 1, "verifyingContract": "VERIFYING"},
+	"message": {
+		"from": {"name": "Cow", "wallet": "FROM"},
+		"to": {"name": "Bob", "wallet": "TO"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+var mailAddresses = []string{
+	"0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC",
+	"0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826",
+	"0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB",
+}
+
+func mailTypedData(t *testing.T, addrs []string) *abi.TypedData {
+	t.Helper()
+	data := strings.NewReplacer(
+		`"VERIFYING"`, `"`+addrs[0]+`"`,
+		`"FROM"`, `"`+addrs[1]+`"`,
+		`"TO"`, `"`+addrs[2]+`"`,
+	).Replace(mail)
+	td, err := abi.ParseTypedData([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return td
+}
+
+func TestTypedDataMail(t *testing.T) {
+	td := mailTypedData(t, mailAddresses)
+	encoded, err := td.EncodeType("Mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded != "Mail(Person from,Person to,string contents)Person(string name,address wallet)" {
+		t.Fatalf("encodeType %s", encoded)
+	}
+	for _, tc := range []struct {
+		name string
+		hash func() ([]byte, error)
+		want string
+	}{
+		{"typeHash", func() ([]byte, error) { return td.TypeHash("Mail") }, "a0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2"},
+		{"domainSeparator", td.DomainSeparator, "f2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f"},
+		{"hashStruct", func() ([]byte, error) { return td.HashStruct("Mail", td.Message) }, "c52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e"},
+		{"digest", td.Hash, "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2"},
+	} {
+		got, err := tc.hash()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if hex.EncodeToString(got) != tc.want {
+			t.Fatalf("%s %x, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TIP-712 hashes the 20 bytes of an address, so the base58 and 41 hex
+// addresses TronWeb gives hash like their 0x form
+func TestTypedDataMailTronAddresses(t *testing.T) {
+	base58 := make([]string, len(mailAddresses))
+	tronHex := make([]string, len(mailAddresses))
+	for i, a := range mailAddresses {
+		b, err := hex.DecodeString(strings.TrimPrefix(a, "0x"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr, err := address.FromEthAddress(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		base58[i] = addr.String()
+		tronHex[i] = addr.Hex()
+	}
+	for _, addrs := range [][]string{base58, tronHex} {
+		hash, err := mailTypedData(t, addrs).Hash()
+		if err != nil {
+			t.Fatalf("%v: %v", addrs, err)
+		}
+		if hex.EncodeToString(hash) != "be609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2" {
+			t.Fatalf("%v: digest %x", addrs, hash)
+		}
+	}
+
+	// a base58 address with a wrong checksum is an error, not another digest
+	bad := append([]string(nil), base58...)
+	bad[1] = bad[1][:len(bad[1])-1] + "1"
+	if _, err := mailTypedData(t, bad).Hash(); err == nil {
+		t.Fatal("base58 address with a wrong checksum accepted")
+	}
+}
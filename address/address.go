@@ -2,7 +2,10 @@ package address
 
 import (
 	"encoding/hex"
+	"fmt"
 	"math/big"
+
+	"golang.org/x/crypto/sha3"
 )
 
 const (
@@ -77,3 +80,18 @@ func FromEthAddress(addr []byte) (Address, error) {
 	a = append(a, addr...)
 	return a, nil
 }
+
+// FromPublicKey returns the address of an uncompressed secp256k1 public key,
+// 65 bytes with the 0x04 prefix or the 64 bytes of X and Y
+func FromPublicKey(pub []byte) (Address, error) {
+	if len(pub) == 65 && pub[0] == 0x04 {
+		pub = pub[1:]
+	}
+	if len(pub) != 64 {
+		return nil, fmt.Errorf("invalid public key length %d", len(pub))
+	}
+	s := sha3.NewLegacyKeccak256()
+	s.Write(pub)
+	hash := s.Sum(nil)
+	return FromEthAddress(hash[12:])
+}
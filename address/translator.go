@@ -1,6 +1,9 @@
 package address
 
 import (
+	"encoding/hex"
+	"strings"
+
 	"github.com/fullstackwang/tron-grpc/abi"
 )
 
@@ -31,6 +34,22 @@ func (d addressTranslator) ToEthAddress(val any) ([]byte, error) {
 	}
 }
 
+// TypedDataAddress parses the addresses of TIP-712 messages, base58 as
+// TronWeb gives them, hex with the 41 prefix or the 0x hex of 20 bytes
+func (d addressTranslator) TypedDataAddress(s string) (any, error) {
+	if len(s) == LengthBase58 {
+		return FromBase58(s)
+	}
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == LengthEthAddress {
+		return FromEthAddress(b)
+	}
+	return FromBytes(b)
+}
+
 func init() {
 	abi.CustomAddressTranslator = &addressTranslator{}
 }
@@ -0,0 +1,57 @@
+package address
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fullstackwang/tron-grpc/abi"
+)
+
+const translatorBase58 = "TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH"
+
+// the abi encoding of addresses takes hex, the looser forms are only for
+// typed data
+func TestTranslatorStrict(t *testing.T) {
+	addr, err := FromBase58(translatorBase58)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := abi.CustomAddressTranslator.ToEthAddress(addr.Hex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, addr.ToEthAddress()) {
+		t.Fatalf("hex: %x, want %x", got, addr.ToEthAddress())
+	}
+	if _, err := abi.CustomAddressTranslator.ToEthAddress(translatorBase58); err == nil {
+		t.Fatal("base58 accepted outside typed data")
+	}
+}
+
+func TestTypedDataAddresses(t *testing.T) {
+	addr, err := FromBase58(translatorBase58)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := func(wallet string) []byte {
+		td := &abi.TypedData{
+			Types: map[string][]abi.TypedDataField{
+				"Person": {{Name: "wallet", Type: "address"}},
+			},
+			PrimaryType: "Person",
+			Domain:      map[string]any{"name": "test"},
+			Message:     map[string]any{"wallet": wallet},
+		}
+		h, err := td.Hash()
+		if err != nil {
+			t.Fatalf("%s: %v", wallet, err)
+		}
+		return h
+	}
+	want := hash(translatorBase58)
+	for _, s := range []string{addr.Hex(), "0x" + addr.Hex()[2:]} {
+		if got := hash(s); !bytes.Equal(got, want) {
+			t.Fatalf("%s hashes to %x, want %x", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const apiKeyHeader = "TRON-PRO-API-KEY"
+
+type apiKey struct {
+	key           string
+	limiter       *tokenBucket
+	classLimiters map[MethodClass]*tokenBucket
+}
+
+func (k *apiKey) wait(ctx context.Context, class MethodClass) error {
+	if err := k.limiter.wait(ctx); err != nil {
+		return err
+	}
+	return k.classLimiters[class].wait(ctx)
+}
+
+// id identifies the key in logs and metrics without leaking it
+func (k *apiKey) id() string {
+	if k.key == "" {
+		return ""
+	}
+	if len(k.key) <= 8 {
+		return "****"
+	}
+	return "****" + k.key[len(k.key)-4:]
+}
+
+func (k *apiKey) attach(ctx context.Context) context.Context {
+	if k.key == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, apiKeyHeader, k.key)
+}
+
+// keyRing rotates over the api keys, a key is left for the next one when
+// it gets rate limited by the node
+type keyRing struct {
+	mu     sync.Mutex
+	keys   []*apiKey
+	active int
+}
+
+func newKeyRing(keys []string, limit *RateLimit, classLimits map[MethodClass]*RateLimit) *keyRing {
+	if len(keys) == 0 {
+		keys = []string{""}
+	}
+	r := &keyRing{}
+	for _, key := range keys {
+		k := &apiKey{
+			key:           key,
+			limiter:       newTokenBucket(limit),
+			classLimiters: make(map[MethodClass]*tokenBucket),
+		}
+		for class, l := range classLimits {
+			k.classLimiters[class] = newTokenBucket(l)
+		}
+		r.keys = append(r.keys, k)
+	}
+	return r
+}
+
+func (r *keyRing) current() (int, *apiKey) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.active, r.keys[r.active]
+}
+
+func (r *keyRing) rotate(from int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active == from {
+		r.active = (from + 1) % len(r.keys)
+	}
+}
+
+// do runs call with the active key. When the node answers ResourceExhausted
+// the next key becomes active, and call is tried again with it if resend
+// is set. A broadcast is resent only when the retry policy allows it
+func (r *keyRing) do(ctx context.Context, class MethodClass, resend bool, call func(context.Context) error) error {
+	for i := 0; ; i++ {
+		idx, key := r.current()
+		if err := key.wait(ctx, class); err != nil {
+			return err
+		}
+		if info := getCallInfo(ctx); info != nil {
+			info.APIKeyID = key.id()
+		}
+		err := call(key.attach(ctx))
+		if status.Code(err) != codes.ResourceExhausted {
+			return err
+		}
+		r.rotate(idx)
+		if !resend || i+1 >= len(r.keys) {
+			return err
+		}
+	}
+}
+
+// SetAPIKeys sets several api keys to rotate over when one gets rate
+// limited. Must be called before Start
+func (c *Client) SetAPIKeys(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiKeys = keys
+}
+
+func (c *Client) getAPIKeys() []string {
+	if len(c.apiKeys) > 0 {
+		return c.apiKeys
+	}
+	if c.apiKey != "" {
+		return []string{c.apiKey}
+	}
+	return nil
+}
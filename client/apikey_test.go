@@ -0,0 +1,93 @@
+package client_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/client"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimited returns a client with the api keys a and b on a node which
+// rate limits key a, and the keys the node saw for method
+func rateLimited(t *testing.T, method string, reply any) (*client.Client, func() []string) {
+	t.Helper()
+	s := testutil.NewServer()
+	t.Cleanup(s.Stop)
+	var mu sync.Mutex
+	var seen []string
+	s.Handle(method, func(ctx context.Context, _ any) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		key := ""
+		if v := md.Get("TRON-PRO-API-KEY"); len(v) > 0 {
+			key = v[0]
+		}
+		mu.Lock()
+		seen = append(seen, key)
+		mu.Unlock()
+		if key == "a" {
+			return nil, status.Error(codes.ResourceExhausted, "rate limited")
+		}
+		return reply, nil
+	})
+	c := client.New("bufnet", "")
+	c.SetAPIKeys("a", "b")
+	if err := c.Start(s.DialOptions()...); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Stop)
+	return c, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		keys := append([]string(nil), seen...)
+		seen = nil
+		return keys
+	}
+}
+
+func TestAPIKeyRotation(t *testing.T) {
+	c, seen := rateLimited(t, "GetAccount", &core.Account{})
+	if _, err := c.GetAccount(context.Background(), &core.Account{}, client.WithRetry(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if keys := seen(); len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("keys %v, want [a b]", keys)
+	}
+}
+
+func TestAPIKeyRotationBroadcast(t *testing.T) {
+	c, seen := rateLimited(t, "BroadcastTransaction", &api.Return{Result: true})
+
+	// without RetryBroadcast the transaction is sent once, the next call
+	// uses the next key
+	_, err := c.BroadcastTransaction(context.Background(), &core.Transaction{})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("got %v, want ResourceExhausted", err)
+	}
+	if keys := seen(); len(keys) != 1 || keys[0] != "a" {
+		t.Fatalf("keys %v, want [a]", keys)
+	}
+	if _, err := c.BroadcastTransaction(context.Background(), &core.Transaction{}); err != nil {
+		t.Fatal(err)
+	}
+	if keys := seen(); len(keys) != 1 || keys[0] != "b" {
+		t.Fatalf("keys %v, want [b]", keys)
+	}
+
+	// with RetryBroadcast it is resent with the next key
+	c, seen = rateLimited(t, "BroadcastTransaction", &api.Return{Result: true})
+	policy := fastRetry(2)
+	policy.RetryBroadcast = true
+	if _, err := c.BroadcastTransaction(context.Background(), &core.Transaction{}, client.WithRetry(policy)); err != nil {
+		t.Fatal(err)
+	}
+	if keys := seen(); len(keys) != 2 || keys[0] != "a" || keys[1] != "b" {
+		t.Fatalf("keys %v, want [a b]", keys)
+	}
+}
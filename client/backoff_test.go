@@ -0,0 +1,32 @@
+package client
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	for attempt, want := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	} {
+		if got := p.backoff(attempt); got != want {
+			t.Fatalf("attempt %d: backoff %v, want %v", attempt, got, want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.backoff(1); d < 100*time.Millisecond || d > 300*time.Millisecond {
+			t.Fatalf("backoff %v outside the jitter of 200ms", d)
+		}
+		if d := p.backoff(10); d > time.Second || d < 500*time.Millisecond {
+			t.Fatalf("capped backoff %v", d)
+		}
+	}
+}
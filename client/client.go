@@ -4,23 +4,50 @@ import (
 	"context"
 	"fmt"
 	"github.com/fullstackwang/tron-grpc/wallet"
+	"sync"
+	"sync/atomic"
 
 	"github.com/fullstackwang/tron-grpc/api"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/credentials"
 	"time"
 )
 
 //go:generate go run ../tools/generator/gen.go
 
+var ErrNotStarted = fmt.Errorf("client not started")
+
+// Client is safe for concurrent use. It dials on Start, or lazily on the
+// first call, and its settings must be done before that
 type Client struct {
-	Signer  Signer
-	address string
-	conn    *grpc.ClientConn
-	client  api.WalletClient
-	timeout time.Duration
-	opts    []grpc.DialOption
-	apiKey  string
+	Signer Signer
+
+	mu               sync.Mutex
+	state            atomic.Pointer[connState]
+	stopped          bool
+	address          string
+	endpoints        []string
+	solidityAddress  string
+	client           api.WalletClient
+	solidity         api.WalletSolidityClient
+	extension        api.WalletExtensionClient
+	database         api.DatabaseClient
+	monitor          api.MonitorClient
+	network          api.NetworkClient
+	timeout          atomic.Int64
+	opts             []grpc.DialOption
+	tls              *TLSConfig
+	perRPCCreds      credentials.PerRPCCredentials
+	apiKey           string
+	apiKeys          []string
+	strategy         Strategy
+	healthInterval   time.Duration
+	maxBlockLag      int64
+	blockInterval    time.Duration
+	retryPolicy      atomic.Pointer[RetryPolicy]
+	rateLimit        *RateLimit
+	methodRateLimits map[MethodClass]*RateLimit
+	interceptors     atomic.Pointer[[]Interceptor]
 }
 
 func New(address, apikey string) *Client {
@@ -29,64 +56,261 @@ func New(address, apikey string) *Client {
 
 func NewWithTimeout(address, apikey string, timeout time.Duration) *Client {
 	client := &Client{
-		address: address,
-		timeout: timeout,
-		apiKey:  apikey,
+		address:        address,
+		apiKey:         apikey,
+		healthInterval: defaultHealthCheckInterval,
+		maxBlockLag:    defaultMaxBlockLag,
+		blockInterval:  defaultBlockInterval,
+	}
+	client.timeout.Store(int64(timeout))
+
+	fullConn := &conn{c: client}
+	solidityConn := &conn{c: client, solidity: true}
+	client.client = api.NewWalletClient(fullConn)
+	client.solidity = api.NewWalletSolidityClient(solidityConn)
+	client.extension = api.NewWalletExtensionClient(solidityConn)
+	client.database = api.NewDatabaseClient(fullConn)
+	client.monitor = api.NewMonitorClient(fullConn)
+	client.network = api.NewNetworkClient(fullConn)
+	return client
+}
+
+// NewWithEndpoints creates a Client that spreads calls over several full nodes
+func NewWithEndpoints(addresses []string, apikey string) *Client {
+	client := New("", apikey)
+	if len(addresses) > 0 {
+		client.address = addresses[0]
+		client.endpoints = addresses
 	}
 	return client
 }
 
 func (c *Client) Address() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.address
 }
 
+// Endpoints returns the full node addresses of the pool
+func (c *Client) Endpoints() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.getEndpoints()
+}
+
+func (c *Client) getEndpoints() []string {
+	if len(c.endpoints) == 0 {
+		return []string{c.address}
+	}
+	return c.endpoints
+}
+
+// SetStrategy sets how a node is picked for each call, must be called before Start
+func (c *Client) SetStrategy(strategy Strategy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.strategy = strategy
+}
+
+// SetHealthCheck sets the probe interval and the max number of blocks a node
+// may lag behind the best node, or behind the clock, before it is taken out
+// of rotation. A zero interval disables health checks. Must be called
+// before Start
+func (c *Client) SetHealthCheck(interval time.Duration, maxBlockLag int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.healthInterval = interval
+	c.maxBlockLag = maxBlockLag
+}
+
+// SetBlockInterval sets the time between two blocks of the network, the
+// head of a node older than max block lag intervals is stale. 3s by
+// default, zero disables the check. Must be called before Start
+func (c *Client) SetBlockInterval(interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.blockInterval = interval
+}
+
+// NodeStatuses returns the health of every node in the pool
+func (c *Client) NodeStatuses() []NodeStatus {
+	st := c.state.Load()
+	if st == nil {
+		return nil
+	}
+	return st.pool.statuses()
+}
+
+// SolidityAddress returns the solidity node endpoint, empty if the
+// solidity services share the full node connection
+func (c *Client) SolidityAddress() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.solidityAddress
+}
+
+// SetSolidityAddress sets a separate endpoint for the WalletSolidity and
+// WalletExtension services, must be called before Start
+func (c *Client) SetSolidityAddress(address string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.solidityAddress = address
+}
+
 func (c *Client) SetPrivateKey(key string) error {
 	var err error
 	c.Signer, err = wallet.FromPrivateKey(key)
 	return err
 }
 
+// SetKeystore sets the signer to the key of an encrypted keystore file
+func (c *Client) SetKeystore(path, password string) error {
+	w, err := wallet.DecryptKeyFile(path, password)
+	if err != nil {
+		return err
+	}
+	c.Signer = w
+	return nil
+}
+
 // SetTimeout for Client connections
 func (c *Client) SetTimeout(timeout time.Duration) {
-	c.timeout = timeout
+	c.timeout.Store(int64(timeout))
+}
+
+func (c *Client) getTimeout() time.Duration {
+	return time.Duration(c.timeout.Load())
+}
+
+// SetDialOptions sets the options used when the client dials lazily on the
+// first call, without TLS nor any option the connection is insecure
+func (c *Client) SetDialOptions(opts ...grpc.DialOption) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.opts = opts
 }
 
 // Start initiate grpc  connection
 func (c *Client) Start(opts ...grpc.DialOption) error {
-	var err error
-	if c.address == "" {
-		c.address = "grpc.trongrid.io:50051"
-	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.opts = opts
-	c.conn, err = grpc.Dial(c.address, opts...)
+	c.stopped = false
+	return c.connect()
+}
 
+// StartContext initiates the grpc connection and blocks until a full node
+// connection is ready or ctx is done
+func (c *Client) StartContext(ctx context.Context, opts ...grpc.DialOption) error {
+	err := c.Start(opts...)
+	if err != nil {
+		return err
+	}
+	st := c.state.Load()
+	if st == nil {
+		return ErrNotStarted
+	}
+	err = st.waitReady(ctx)
 	if err != nil {
-		return fmt.Errorf("Connecting GRPC Client: %v", err)
+		c.Stop()
+		return fmt.Errorf("Connecting GRPC Client: %w", err)
 	}
-	c.client = api.NewWalletClient(c.conn)
 	return nil
 }
 
-// Stop GRPC Connection
+// Stop GRPC Connection, calls made after Stop fail with ErrNotStarted
+// until the client is started again. The calls in flight are given up to
+// 10s to finish
 func (c *Client) Stop() {
-	if c.conn != nil {
-		c.conn.Close()
+	c.mu.Lock()
+	c.stopped = true
+	old := c.state.Swap(nil)
+	c.mu.Unlock()
+	// draining without c.mu, a slow call does not block the other methods
+	if old != nil {
+		old.close()
 	}
 }
 
-// Reconnect GRPC
+// Reconnect GRPC, calls in flight finish on the old connection
 func (c *Client) Reconnect(url string) error {
-	c.Stop()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	if url != "" {
 		c.address = url
+		c.endpoints = nil
 	}
-	return c.Start(c.opts...)
+	c.stopped = false
+	return c.connect()
 }
 
-func (c *Client) makeContext(parent context.Context) (context.Context, context.CancelFunc) {
-	ctx, cancel := context.WithTimeout(parent, c.timeout)
-	if c.apiKey != "" {
-		ctx = metadata.AppendToOutgoingContext(ctx, "TRON-PRO-API-KEY", c.apiKey)
+// connect dials the nodes and replaces the current connection, c.mu must be held
+func (c *Client) connect() error {
+	if c.address == "" {
+		c.address = "grpc.trongrid.io:50051"
 	}
-	return ctx, cancel
+	opts, err := c.getDialOptions()
+	if err != nil {
+		return err
+	}
+
+	keys := newKeyRing(c.getAPIKeys(), c.rateLimit, c.methodRateLimits)
+	p, err := dialPool(c.getEndpoints(), opts)
+	if err != nil {
+		return err
+	}
+	p.strategy = c.strategy
+	p.interval = c.healthInterval
+	p.maxLag = c.maxBlockLag
+	p.blockInterval = c.blockInterval
+	p.timeout = c.getTimeout
+	p.keys = keys
+
+	st := newConnState(keys, p)
+	if c.solidityAddress != "" {
+		st.solidityPool, err = dialPool([]string{c.solidityAddress}, opts)
+		if err != nil {
+			p.close()
+			return err
+		}
+	}
+	p.start()
+
+	if old := c.state.Swap(st); old != nil {
+		// the calls in flight finish on the old connection
+		go old.close()
+	}
+	return nil
+}
+
+// acquire returns the current connection, dialing it on the first call.
+// The connection must be released when the call is done
+func (c *Client) acquire() (*connState, error) {
+	for {
+		st := c.state.Load()
+		if st == nil {
+			c.mu.Lock()
+			if c.stopped {
+				c.mu.Unlock()
+				return nil, ErrNotStarted
+			}
+			if c.state.Load() == nil {
+				if err := c.connect(); err != nil {
+					c.mu.Unlock()
+					return nil, err
+				}
+			}
+			c.mu.Unlock()
+			continue
+		}
+		if st.acquire() {
+			return st, nil
+		}
+	}
+}
+
+// makeContext prepares the context of a call, the timeout and the api key
+// are applied to every attempt when the call is invoked
+func (c *Client) makeContext(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithCancel(parent)
 }
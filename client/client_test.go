@@ -0,0 +1,108 @@
+package client_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/client"
+	"github.com/fullstackwang/tron-grpc/testutil"
+)
+
+// the setters may run while calls dial lazily, run with -race
+func TestSettersDuringCalls(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	c := client.New("bufnet", "")
+	c.SetDialOptions(s.DialOptions()...)
+	defer c.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, err := c.GetNowBlock2(context.Background(), &api.EmptyMessage{}); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	for j := 0; j < 20; j++ {
+		c.SetRetryPolicy(&client.DefaultRetryPolicy)
+		c.Use(func(ctx context.Context, info *client.CallInfo, req, reply any, next client.Invoker) error {
+			return next(ctx, info, req, reply)
+		})
+		c.SetStrategy(client.LeastLatency)
+		c.SetHealthCheck(time.Second, 10)
+		c.SetAPIKeys("a", "b")
+		c.SetRateLimit(nil)
+		c.SetMethodRateLimit(client.ClassRead, nil)
+		c.SetSolidityAddress("")
+		_ = c.SolidityAddress()
+	}
+	wg.Wait()
+}
+
+func TestStopDuringCall(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	release := make(chan struct{})
+	s.Handle("GetNowBlock2", func(ctx context.Context, req any) (any, error) {
+		<-release
+		return &api.BlockExtention{}, nil
+	})
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetTimeout(time.Minute)
+
+	called := make(chan error)
+	go func() {
+		_, err := c.GetNowBlock2(context.Background(), &api.EmptyMessage{})
+		called <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// reconnecting does not wait for the call
+	done := make(chan struct{})
+	go func() {
+		c.Reconnect("")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Reconnect waits for the call in flight")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		c.Stop()
+		close(stopped)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	// Stop drains without holding the client lock
+	locked := make(chan struct{})
+	go func() {
+		c.SetStrategy(client.RoundRobin)
+		_ = c.Address()
+		close(locked)
+	}()
+	select {
+	case <-locked:
+	case <-time.After(time.Second):
+		t.Fatal("the client is locked while Stop drains")
+	}
+
+	close(release)
+	if err := <-called; err != nil {
+		t.Fatal(err)
+	}
+	<-stopped
+}
@@ -0,0 +1,407 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/wallet"
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration written as "5s" or "200ms" in config files
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Config describes a Client, it can be loaded from yaml, json or the environment
+type Config struct {
+	// Network is a preset name, mainnet, shasta or nile, filling the
+	// endpoints that are not set
+	Network          string           `json:"network,omitempty" yaml:"network,omitempty"`
+	Endpoints        []string         `json:"endpoints,omitempty" yaml:"endpoints,omitempty"`
+	SolidityEndpoint string           `json:"solidity_endpoint,omitempty" yaml:"solidity_endpoint,omitempty"`
+	TLS              *TLSConfig       `json:"tls,omitempty" yaml:"tls,omitempty"`
+	APIKeys          []string         `json:"api_keys,omitempty" yaml:"api_keys,omitempty"`
+	Timeout          Duration         `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	Strategy         string           `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+	HealthCheck      *HealthCheck     `json:"health_check,omitempty" yaml:"health_check,omitempty"`
+	Retry            *RetryConfig     `json:"retry,omitempty" yaml:"retry,omitempty"`
+	RateLimit        *RateLimitConfig `json:"rate_limit,omitempty" yaml:"rate_limit,omitempty"`
+	Signer           *SignerConfig    `json:"signer,omitempty" yaml:"signer,omitempty"`
+}
+
+// HealthCheck sets the node health checks, the fields left empty keep the
+// default interval and max block lag
+type HealthCheck struct {
+	Interval    Duration `json:"interval,omitempty" yaml:"interval,omitempty"`
+	MaxBlockLag int64    `json:"max_block_lag,omitempty" yaml:"max_block_lag,omitempty"`
+	// Disabled turns the health checks off
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty"`
+}
+
+// RetryConfig sets a RetryPolicy, the fields left empty keep the values of DefaultRetryPolicy
+type RetryConfig struct {
+	MaxAttempts    int      `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+	InitialBackoff Duration `json:"initial_backoff,omitempty" yaml:"initial_backoff,omitempty"`
+	MaxBackoff     Duration `json:"max_backoff,omitempty" yaml:"max_backoff,omitempty"`
+	Multiplier     float64  `json:"multiplier,omitempty" yaml:"multiplier,omitempty"`
+	Jitter         float64  `json:"jitter,omitempty" yaml:"jitter,omitempty"`
+	RetryBroadcast bool     `json:"retry_broadcast,omitempty" yaml:"retry_broadcast,omitempty"`
+}
+
+// RateLimitConfig sets the limit per api key and the limits per method class
+type RateLimitConfig struct {
+	Rate      float64    `json:"rate,omitempty" yaml:"rate,omitempty"`
+	Burst     int        `json:"burst,omitempty" yaml:"burst,omitempty"`
+	Read      *RateLimit `json:"read,omitempty" yaml:"read,omitempty"`
+	Write     *RateLimit `json:"write,omitempty" yaml:"write,omitempty"`
+	Broadcast *RateLimit `json:"broadcast,omitempty" yaml:"broadcast,omitempty"`
+}
+
+// SignerConfig tells where the private key of the client signer comes from,
+// the first field set is used
+type SignerConfig struct {
+	PrivateKey     string `json:"private_key,omitempty" yaml:"private_key,omitempty"`
+	PrivateKeyEnv  string `json:"private_key_env,omitempty" yaml:"private_key_env,omitempty"`
+	PrivateKeyFile string `json:"private_key_file,omitempty" yaml:"private_key_file,omitempty"`
+	// Keystore is an encrypted keystore file, unlocked with the password
+	// read from KeystorePasswordEnv or KeystorePasswordFile
+	Keystore             string `json:"keystore,omitempty" yaml:"keystore,omitempty"`
+	KeystorePasswordEnv  string `json:"keystore_password_env,omitempty" yaml:"keystore_password_env,omitempty"`
+	KeystorePasswordFile string `json:"keystore_password_file,omitempty" yaml:"keystore_password_file,omitempty"`
+}
+
+// LoadConfig reads a yaml or json config file, the format is chosen by the
+// file extension
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, cfg)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	default:
+		return nil, fmt.Errorf("unknown config format %s", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadConfigFromEnv reads a config from the environment, see Config.LoadEnv
+func LoadConfigFromEnv(prefix string) (*Config, error) {
+	cfg := &Config{}
+	return cfg, cfg.LoadEnv(prefix)
+}
+
+// LoadEnv overrides the config with the environment variables set, with
+// prefix "TRON_" they are TRON_NETWORK, TRON_ENDPOINTS, TRON_SOLIDITY_ENDPOINT,
+// TRON_TLS, TRON_TLS_CA_FILE, TRON_TLS_CERT_FILE, TRON_TLS_KEY_FILE,
+// TRON_TLS_SERVER_NAME, TRON_API_KEYS, TRON_TIMEOUT, TRON_STRATEGY,
+// TRON_RETRY_MAX_ATTEMPTS, TRON_RATE_LIMIT, TRON_RATE_BURST, TRON_PRIVATE_KEY,
+// TRON_KEYSTORE and TRON_KEYSTORE_PASSWORD_FILE. Lists are comma separated
+func (cfg *Config) LoadEnv(prefix string) error {
+	env := func(name string) (string, bool) {
+		v, ok := os.LookupEnv(prefix + name)
+		return strings.TrimSpace(v), ok && strings.TrimSpace(v) != ""
+	}
+	list := func(v string) []string {
+		var ret []string
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				ret = append(ret, s)
+			}
+		}
+		return ret
+	}
+
+	if v, ok := env("NETWORK"); ok {
+		cfg.Network = v
+	}
+	if v, ok := env("ENDPOINTS"); ok {
+		cfg.Endpoints = list(v)
+	}
+	if v, ok := env("SOLIDITY_ENDPOINT"); ok {
+		cfg.SolidityEndpoint = v
+	}
+	if v, ok := env("TLS"); ok {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("%sTLS: %v", prefix, err)
+		}
+		if !enabled {
+			cfg.TLS = nil
+		} else if cfg.TLS == nil {
+			cfg.TLS = &TLSConfig{}
+		}
+	}
+	if v, ok := env("TLS_CA_FILE"); ok {
+		if cfg.TLS == nil {
+			cfg.TLS = &TLSConfig{}
+		}
+		cfg.TLS.CAFile = v
+	}
+	if v, ok := env("TLS_CERT_FILE"); ok {
+		if cfg.TLS == nil {
+			cfg.TLS = &TLSConfig{}
+		}
+		cfg.TLS.CertFile = v
+	}
+	if v, ok := env("TLS_KEY_FILE"); ok {
+		if cfg.TLS == nil {
+			cfg.TLS = &TLSConfig{}
+		}
+		cfg.TLS.KeyFile = v
+	}
+	if v, ok := env("TLS_SERVER_NAME"); ok {
+		if cfg.TLS == nil {
+			cfg.TLS = &TLSConfig{}
+		}
+		cfg.TLS.ServerName = v
+	}
+	if v, ok := env("API_KEYS"); ok {
+		cfg.APIKeys = list(v)
+	}
+	if v, ok := env("TIMEOUT"); ok {
+		if err := cfg.Timeout.UnmarshalText([]byte(v)); err != nil {
+			return fmt.Errorf("%sTIMEOUT: %v", prefix, err)
+		}
+	}
+	if v, ok := env("STRATEGY"); ok {
+		cfg.Strategy = v
+	}
+	if v, ok := env("RETRY_MAX_ATTEMPTS"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%sRETRY_MAX_ATTEMPTS: %v", prefix, err)
+		}
+		if cfg.Retry == nil {
+			cfg.Retry = &RetryConfig{}
+		}
+		cfg.Retry.MaxAttempts = n
+	}
+	if v, ok := env("RATE_LIMIT"); ok {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("%sRATE_LIMIT: %v", prefix, err)
+		}
+		if cfg.RateLimit == nil {
+			cfg.RateLimit = &RateLimitConfig{}
+		}
+		cfg.RateLimit.Rate = rate
+	}
+	if v, ok := env("RATE_BURST"); ok {
+		burst, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("%sRATE_BURST: %v", prefix, err)
+		}
+		if cfg.RateLimit == nil {
+			cfg.RateLimit = &RateLimitConfig{}
+		}
+		cfg.RateLimit.Burst = burst
+	}
+	if v, ok := env("PRIVATE_KEY"); ok {
+		cfg.Signer = &SignerConfig{PrivateKey: v}
+	}
+	if v, ok := env("KEYSTORE"); ok {
+		cfg.Signer = &SignerConfig{Keystore: v}
+		cfg.Signer.KeystorePasswordFile, _ = env("KEYSTORE_PASSWORD_FILE")
+	}
+	return nil
+}
+
+func (cfg *Config) getStrategy() (Strategy, error) {
+	switch cfg.Strategy {
+	case "", "round_robin":
+		return RoundRobin, nil
+	case "least_latency":
+		return LeastLatency, nil
+	default:
+		return 0, fmt.Errorf("unknown strategy %s", cfg.Strategy)
+	}
+}
+
+func (cfg *RetryConfig) policy() *RetryPolicy {
+	p := DefaultRetryPolicy
+	if cfg.MaxAttempts > 0 {
+		p.MaxAttempts = cfg.MaxAttempts
+	}
+	if cfg.InitialBackoff > 0 {
+		p.InitialBackoff = time.Duration(cfg.InitialBackoff)
+	}
+	if cfg.MaxBackoff > 0 {
+		p.MaxBackoff = time.Duration(cfg.MaxBackoff)
+	}
+	if cfg.Multiplier > 0 {
+		p.Multiplier = cfg.Multiplier
+	}
+	if cfg.Jitter > 0 {
+		p.Jitter = cfg.Jitter
+	}
+	p.RetryBroadcast = cfg.RetryBroadcast
+	return &p
+}
+
+// settings returns the interval and max block lag of SetHealthCheck
+func (cfg *HealthCheck) settings() (time.Duration, int64) {
+	if cfg.Disabled {
+		return 0, 0
+	}
+	interval, maxLag := time.Duration(cfg.Interval), cfg.MaxBlockLag
+	if interval <= 0 {
+		interval = defaultHealthCheckInterval
+	}
+	if maxLag <= 0 {
+		maxLag = defaultMaxBlockLag
+	}
+	return interval, maxLag
+}
+
+func (cfg *SignerConfig) privateKey() (string, error) {
+	switch {
+	case cfg.PrivateKey != "":
+		return cfg.PrivateKey, nil
+	case cfg.PrivateKeyEnv != "":
+		key := os.Getenv(cfg.PrivateKeyEnv)
+		if key == "" {
+			return "", fmt.Errorf("environment variable %s is empty", cfg.PrivateKeyEnv)
+		}
+		return key, nil
+	case cfg.PrivateKeyFile != "":
+		data, err := os.ReadFile(cfg.PrivateKeyFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	return "", nil
+}
+
+func (cfg *SignerConfig) keystorePassword() (string, error) {
+	switch {
+	case cfg.KeystorePasswordEnv != "":
+		password, ok := os.LookupEnv(cfg.KeystorePasswordEnv)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", cfg.KeystorePasswordEnv)
+		}
+		return password, nil
+	case cfg.KeystorePasswordFile != "":
+		data, err := os.ReadFile(cfg.KeystorePasswordFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return "", fmt.Errorf("keystore %s needs a password env or file", cfg.Keystore)
+}
+
+// signer returns the configured signer, nil if none is set
+func (cfg *SignerConfig) signer() (Signer, error) {
+	if cfg.Keystore != "" {
+		password, err := cfg.keystorePassword()
+		if err != nil {
+			return nil, err
+		}
+		return wallet.DecryptKeyFile(cfg.Keystore, password)
+	}
+	key, err := cfg.privateKey()
+	if err != nil || key == "" {
+		return nil, err
+	}
+	return wallet.FromPrivateKey(key)
+}
+
+// NewFromConfig creates a Client from cfg and starts it, it blocks until a
+// full node connection is ready or ctx is done
+func NewFromConfig(ctx context.Context, cfg *Config) (*Client, error) {
+	c, err := newFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	err = c.StartContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// newFromConfig creates the Client of cfg without starting it
+func newFromConfig(cfg *Config) (*Client, error) {
+	endpoints := cfg.Endpoints
+	solidityEndpoint := cfg.SolidityEndpoint
+	var blockInterval time.Duration
+	if cfg.Network != "" {
+		network, err := GetNetwork(cfg.Network)
+		if err != nil {
+			return nil, err
+		}
+		if len(endpoints) == 0 {
+			endpoints = network.Endpoints
+		}
+		if solidityEndpoint == "" {
+			solidityEndpoint = network.SolidityEndpoint
+		}
+		blockInterval = network.BlockInterval
+	}
+
+	c := NewWithEndpoints(endpoints, "")
+	if blockInterval > 0 {
+		c.SetBlockInterval(blockInterval)
+	}
+	c.SetAPIKeys(cfg.APIKeys...)
+	c.SetSolidityAddress(solidityEndpoint)
+	if cfg.Timeout > 0 {
+		c.SetTimeout(time.Duration(cfg.Timeout))
+	}
+	strategy, err := cfg.getStrategy()
+	if err != nil {
+		return nil, err
+	}
+	c.SetStrategy(strategy)
+	if cfg.HealthCheck != nil {
+		c.SetHealthCheck(cfg.HealthCheck.settings())
+	}
+	if cfg.Retry != nil {
+		c.SetRetryPolicy(cfg.Retry.policy())
+	}
+	if cfg.RateLimit != nil {
+		c.SetRateLimit(&RateLimit{Rate: cfg.RateLimit.Rate, Burst: cfg.RateLimit.Burst})
+		c.SetMethodRateLimit(ClassRead, cfg.RateLimit.Read)
+		c.SetMethodRateLimit(ClassWrite, cfg.RateLimit.Write)
+		c.SetMethodRateLimit(ClassBroadcast, cfg.RateLimit.Broadcast)
+	}
+	if cfg.Signer != nil {
+		signer, err := cfg.Signer.signer()
+		if err != nil {
+			return nil, err
+		}
+		if signer != nil {
+			c.Signer = signer
+		}
+	}
+
+	c.SetTLS(cfg.TLS)
+	return c, nil
+}
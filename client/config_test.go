@@ -0,0 +1,57 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConfigHealthCheckDefaults(t *testing.T) {
+	for _, tc := range []struct {
+		yaml     string
+		interval time.Duration
+		maxLag   int64
+	}{
+		{"health_check:\n  interval: 5s\n", 5 * time.Second, defaultMaxBlockLag},
+		{"health_check:\n  max_block_lag: 5\n", defaultHealthCheckInterval, 5},
+		{"health_check: {}\n", defaultHealthCheckInterval, defaultMaxBlockLag},
+		{"health_check:\n  disabled: true\n", 0, 0},
+		{"endpoints: [a, b]\n", defaultHealthCheckInterval, defaultMaxBlockLag},
+	} {
+		path := filepath.Join(t.TempDir(), "tron.yaml")
+		if err := os.WriteFile(path, []byte(tc.yaml), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadConfig(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := newFromConfig(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.healthInterval != tc.interval || c.maxBlockLag != tc.maxLag {
+			t.Errorf("%q: health check %v/%d, want %v/%d", tc.yaml, c.healthInterval, c.maxBlockLag, tc.interval, tc.maxLag)
+		}
+	}
+}
+
+func TestConfigNetworkBlockInterval(t *testing.T) {
+	nile := Nile
+	nile.Name = "slow"
+	nile.BlockInterval = 6 * time.Second
+	networks[nile.Name] = nile
+	defer delete(networks, nile.Name)
+
+	c, err := newFromConfig(&Config{Network: "slow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.blockInterval != nile.BlockInterval {
+		t.Fatalf("block interval %v, want %v", c.blockInterval, nile.BlockInterval)
+	}
+	if c.endpoints[0] != Nile.Endpoints[0] {
+		t.Fatalf("endpoints %v, want %v", c.endpoints, Nile.Endpoints)
+	}
+}
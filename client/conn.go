@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc"
+)
+
+// conn sits between the generated api clients and the connections to the
+// nodes, every call of the Client goes through its Invoke
+type conn struct {
+	c        *Client
+	solidity bool
+}
+
+func (cc *conn) Invoke(ctx context.Context, method string, args any, reply any, opts ...grpc.CallOption) error {
+	info := &CallInfo{Method: method}
+	invoker := chainInterceptors(cc.c.getInterceptors(), func(ctx context.Context, info *CallInfo, req, reply any) error {
+		return cc.invoke(ctx, info, req, reply, opts)
+	})
+	return invoker(withCallInfo(ctx, info), info, args, reply)
+}
+
+func (cc *conn) invoke(ctx context.Context, info *CallInfo, args, reply any, opts []grpc.CallOption) error {
+	st, err := cc.c.acquire()
+	if err != nil {
+		return err
+	}
+	defer st.release()
+
+	next := st.getPool(cc.solidity)
+	policy := cc.c.getRetryPolicy(info.Method, opts)
+	class := getMethodClass(info.Method)
+	// getRetryPolicy has no policy for a broadcast without RetryBroadcast
+	resend := class != ClassBroadcast || policy != nil
+	return withRetry(ctx, policy, func(ctx context.Context) error {
+		return st.keys.do(ctx, class, resend, func(ctx context.Context) error {
+			info.Attempts++
+			ctx, cancel := context.WithTimeout(ctx, cc.c.getTimeout())
+			defer cancel()
+			return next.Invoke(ctx, info.Method, args, reply, opts...)
+		})
+	})
+}
+
+func (cc *conn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	st, err := cc.c.acquire()
+	if err != nil {
+		return nil, err
+	}
+	stream, err := st.getPool(cc.solidity).NewStream(ctx, desc, method, opts...)
+	if err != nil {
+		st.release()
+		return nil, err
+	}
+	return newLeasedStream(ctx, stream, st.release), nil
+}
+
+// leasedStream holds the lease of the connection until the stream ends, on
+// an error, io.EOF included, or when its context is done
+type leasedStream struct {
+	grpc.ClientStream
+	once    sync.Once
+	done    chan struct{}
+	release func()
+}
+
+func newLeasedStream(ctx context.Context, stream grpc.ClientStream, release func()) *leasedStream {
+	s := &leasedStream{ClientStream: stream, done: make(chan struct{}), release: release}
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.end()
+		case <-s.done:
+		}
+	}()
+	return s
+}
+
+func (s *leasedStream) end() {
+	s.once.Do(func() {
+		close(s.done)
+		s.release()
+	})
+}
+
+func (s *leasedStream) SendMsg(m any) error {
+	err := s.ClientStream.SendMsg(m)
+	if err != nil {
+		s.end()
+	}
+	return err
+}
+
+func (s *leasedStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.end()
+	}
+	return err
+}
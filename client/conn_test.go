@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+type fakeStream struct {
+	grpc.ClientStream
+	msgs int
+}
+
+func (s *fakeStream) RecvMsg(any) error {
+	if s.msgs == 0 {
+		return io.EOF
+	}
+	s.msgs--
+	return nil
+}
+
+func TestLeasedStreamReleasesOnEOF(t *testing.T) {
+	released := 0
+	s := newLeasedStream(context.Background(), &fakeStream{msgs: 2}, func() { released++ })
+	for i := 0; i < 2; i++ {
+		if err := s.RecvMsg(nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if released != 0 {
+		t.Fatal("released while the stream is open")
+	}
+	if err := s.RecvMsg(nil); err != io.EOF {
+		t.Fatal(err)
+	}
+	s.RecvMsg(nil)
+	if released != 1 {
+		t.Fatalf("released %d times, want 1", released)
+	}
+}
+
+func TestLeasedStreamReleasesOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	released := make(chan struct{})
+	newLeasedStream(ctx, &fakeStream{msgs: 1}, func() { close(released) })
+	cancel()
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("not released when the context is done")
+	}
+}
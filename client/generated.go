@@ -846,3 +846,291 @@ func (c *Client) GetBlock(ctx context.Context, in *api.BlockReq, opts ...grpc.Ca
 	defer cancel()
 	return c.client.GetBlock(ctx, in, opts...)
 }
+
+func (s *SolidityClient) GetAccount(ctx context.Context, in *core.Account, opts ...grpc.CallOption) (*core.Account, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetAccount(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetAccountById(ctx context.Context, in *core.Account, opts ...grpc.CallOption) (*core.Account, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetAccountById(ctx, in, opts...)
+}
+
+func (s *SolidityClient) ListWitnesses(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.WitnessList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.ListWitnesses(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetAssetIssueList(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.AssetIssueList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetAssetIssueList(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetPaginatedAssetIssueList(ctx context.Context, in *api.PaginatedMessage, opts ...grpc.CallOption) (*api.AssetIssueList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetPaginatedAssetIssueList(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetAssetIssueByName(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*core.AssetIssueContract, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetAssetIssueByName(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetAssetIssueListByName(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*api.AssetIssueList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetAssetIssueListByName(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetAssetIssueById(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*core.AssetIssueContract, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetAssetIssueById(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetNowBlock(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*core.Block, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetNowBlock(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetNowBlock2(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.BlockExtention, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetNowBlock2(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetBlockByNum(ctx context.Context, in *api.NumberMessage, opts ...grpc.CallOption) (*core.Block, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetBlockByNum(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetBlockByNum2(ctx context.Context, in *api.NumberMessage, opts ...grpc.CallOption) (*api.BlockExtention, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetBlockByNum2(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetTransactionCountByBlockNum(ctx context.Context, in *api.NumberMessage, opts ...grpc.CallOption) (*api.NumberMessage, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetTransactionCountByBlockNum(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetDelegatedResource(ctx context.Context, in *api.DelegatedResourceMessage, opts ...grpc.CallOption) (*api.DelegatedResourceList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetDelegatedResource(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetDelegatedResourceAccountIndex(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*core.DelegatedResourceAccountIndex, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetDelegatedResourceAccountIndex(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetExchangeById(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*core.Exchange, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetExchangeById(ctx, in, opts...)
+}
+
+func (s *SolidityClient) ListExchanges(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.ExchangeList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.ListExchanges(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetTransactionById(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*core.Transaction, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetTransactionById(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetTransactionInfoById(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*core.TransactionInfo, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetTransactionInfoById(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GenerateAddress(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.AddressPrKeyPairMessage, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GenerateAddress(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetMerkleTreeVoucherInfo(ctx context.Context, in *core.OutputPointInfo, opts ...grpc.CallOption) (*core.IncrementalMerkleVoucherInfo, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetMerkleTreeVoucherInfo(ctx, in, opts...)
+}
+
+func (s *SolidityClient) ScanNoteByIvk(ctx context.Context, in *api.IvkDecryptParameters, opts ...grpc.CallOption) (*api.DecryptNotes, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.ScanNoteByIvk(ctx, in, opts...)
+}
+
+func (s *SolidityClient) ScanAndMarkNoteByIvk(ctx context.Context, in *api.IvkDecryptAndMarkParameters, opts ...grpc.CallOption) (*api.DecryptNotesMarked, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.ScanAndMarkNoteByIvk(ctx, in, opts...)
+}
+
+func (s *SolidityClient) ScanNoteByOvk(ctx context.Context, in *api.OvkDecryptParameters, opts ...grpc.CallOption) (*api.DecryptNotes, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.ScanNoteByOvk(ctx, in, opts...)
+}
+
+func (s *SolidityClient) IsSpend(ctx context.Context, in *api.NoteParameters, opts ...grpc.CallOption) (*api.SpendResult, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.IsSpend(ctx, in, opts...)
+}
+
+func (s *SolidityClient) ScanShieldedTRC20NotesByIvk(ctx context.Context, in *api.IvkDecryptTRC20Parameters, opts ...grpc.CallOption) (*api.DecryptNotesTRC20, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.ScanShieldedTRC20NotesByIvk(ctx, in, opts...)
+}
+
+func (s *SolidityClient) ScanShieldedTRC20NotesByOvk(ctx context.Context, in *api.OvkDecryptTRC20Parameters, opts ...grpc.CallOption) (*api.DecryptNotesTRC20, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.ScanShieldedTRC20NotesByOvk(ctx, in, opts...)
+}
+
+func (s *SolidityClient) IsShieldedTRC20ContractNoteSpent(ctx context.Context, in *api.NfTRC20Parameters, opts ...grpc.CallOption) (*api.NullifierResult, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.IsShieldedTRC20ContractNoteSpent(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetRewardInfo(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*api.NumberMessage, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetRewardInfo(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetBrokerageInfo(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*api.NumberMessage, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetBrokerageInfo(ctx, in, opts...)
+}
+
+func (s *SolidityClient) TriggerConstantContract(ctx context.Context, in *core.TriggerSmartContract, opts ...grpc.CallOption) (*api.TransactionExtention, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.TriggerConstantContract(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetTransactionInfoByBlockNum(ctx context.Context, in *api.NumberMessage, opts ...grpc.CallOption) (*api.TransactionInfoList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetTransactionInfoByBlockNum(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetMarketOrderById(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*core.MarketOrder, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetMarketOrderById(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetMarketOrderByAccount(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*core.MarketOrderList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetMarketOrderByAccount(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetMarketPriceByPair(ctx context.Context, in *core.MarketOrderPair, opts ...grpc.CallOption) (*core.MarketPriceList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetMarketPriceByPair(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetMarketOrderListByPair(ctx context.Context, in *core.MarketOrderPair, opts ...grpc.CallOption) (*core.MarketOrderList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetMarketOrderListByPair(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetMarketPairList(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*core.MarketOrderPairList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetMarketPairList(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetBurnTrx(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.NumberMessage, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetBurnTrx(ctx, in, opts...)
+}
+
+func (s *SolidityClient) GetBlock(ctx context.Context, in *api.BlockReq, opts ...grpc.CallOption) (*api.BlockExtention, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.solidity.GetBlock(ctx, in, opts...)
+}
+
+func (s *ExtensionClient) GetTransactionsFromThis(ctx context.Context, in *api.AccountPaginated, opts ...grpc.CallOption) (*api.TransactionList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.extension.GetTransactionsFromThis(ctx, in, opts...)
+}
+
+func (s *ExtensionClient) GetTransactionsFromThis2(ctx context.Context, in *api.AccountPaginated, opts ...grpc.CallOption) (*api.TransactionListExtention, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.extension.GetTransactionsFromThis2(ctx, in, opts...)
+}
+
+func (s *ExtensionClient) GetTransactionsToThis(ctx context.Context, in *api.AccountPaginated, opts ...grpc.CallOption) (*api.TransactionList, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.extension.GetTransactionsToThis(ctx, in, opts...)
+}
+
+func (s *ExtensionClient) GetTransactionsToThis2(ctx context.Context, in *api.AccountPaginated, opts ...grpc.CallOption) (*api.TransactionListExtention, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.extension.GetTransactionsToThis2(ctx, in, opts...)
+}
+
+func (s *DatabaseClient) GetBlockReference(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*api.BlockReference, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.database.GetBlockReference(ctx, in, opts...)
+}
+
+func (s *DatabaseClient) GetDynamicProperties(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*core.DynamicProperties, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.database.GetDynamicProperties(ctx, in, opts...)
+}
+
+func (s *DatabaseClient) GetNowBlock(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*core.Block, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.database.GetNowBlock(ctx, in, opts...)
+}
+
+func (s *DatabaseClient) GetBlockByNum(ctx context.Context, in *api.NumberMessage, opts ...grpc.CallOption) (*core.Block, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.database.GetBlockByNum(ctx, in, opts...)
+}
+
+func (s *MonitorClient) GetStatsInfo(ctx context.Context, in *api.EmptyMessage, opts ...grpc.CallOption) (*core.MetricsInfo, error) {
+	ctx, cancel := s.c.makeContext(ctx)
+	defer cancel()
+	return s.c.monitor.GetStatsInfo(ctx, in, opts...)
+}
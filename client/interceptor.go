@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+)
+
+// CallInfo describes one call of the Client as seen by the interceptors.
+// Node and APIKeyID are filled while the call is invoked, they belong to
+// the last attempt
+type CallInfo struct {
+	// Method is the full rpc method, like /protocol.Wallet/GetAccount
+	Method   string
+	Node     string
+	APIKeyID string
+	Attempts int
+}
+
+// Invoker runs a call, req and reply are the typed request and response messages
+type Invoker func(ctx context.Context, info *CallInfo, req, reply any) error
+
+// Interceptor wraps every call of the Client, it must call next to go on
+type Interceptor func(ctx context.Context, info *CallInfo, req, reply any, next Invoker) error
+
+type callInfoKey struct{}
+
+func withCallInfo(ctx context.Context, info *CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+func getCallInfo(ctx context.Context) *CallInfo {
+	info, _ := ctx.Value(callInfoKey{}).(*CallInfo)
+	return info
+}
+
+// Use appends interceptors to the chain, the first one is the outermost.
+// Must be called before Start
+func (c *Client) Use(interceptors ...Interceptor) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var chain []Interceptor
+	if old := c.interceptors.Load(); old != nil {
+		chain = append(chain, *old...)
+	}
+	chain = append(chain, interceptors...)
+	c.interceptors.Store(&chain)
+}
+
+func (c *Client) getInterceptors() []Interceptor {
+	if chain := c.interceptors.Load(); chain != nil {
+		return *chain
+	}
+	return nil
+}
+
+func chainInterceptors(interceptors []Interceptor, invoker Invoker) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoker
+		invoker = func(ctx context.Context, info *CallInfo, req, reply any) error {
+			return interceptor(ctx, info, req, reply, next)
+		}
+	}
+	return invoker
+}
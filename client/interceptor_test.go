@@ -0,0 +1,302 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/fullstackwang/tron-grpc/client"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const getAccount = "/protocol.Wallet/GetAccount"
+
+// flaky returns a client on a node whose GetAccount succeeds once, then
+// fails with NotFound, interceptors are installed before Start
+func flaky(t *testing.T, interceptors ...client.Interceptor) *client.Client {
+	t.Helper()
+	s := testutil.NewServer()
+	t.Cleanup(s.Stop)
+	calls := new(atomic.Int32)
+	s.Handle("GetAccount", func(context.Context, any) (any, error) {
+		if calls.Add(1) == 1 {
+			return &core.Account{}, nil
+		}
+		return nil, status.Error(codes.NotFound, "no account")
+	})
+	c := client.New("bufnet", "")
+	c.Use(interceptors...)
+	if err := c.Start(s.DialOptions()...); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Stop)
+	return c
+}
+
+func callTwice(t *testing.T, c *client.Client) {
+	t.Helper()
+	if _, err := c.GetAccount(context.Background(), &core.Account{}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.GetAccount(context.Background(), &core.Account{}, client.WithRetry(nil))
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("got %v, want NotFound", err)
+	}
+}
+
+func TestInterceptorOrder(t *testing.T) {
+	var order []string
+	record := func(name string) client.Interceptor {
+		return func(ctx context.Context, info *client.CallInfo, req, reply any, next client.Invoker) error {
+			order = append(order, name+" in")
+			err := next(ctx, info, req, reply)
+			order = append(order, name+" out")
+			return err
+		}
+	}
+	s := testutil.NewServer()
+	defer s.Stop()
+	c := client.New("bufnet", "")
+	c.Use(record("a"), record("b"))
+	c.Use(record("c"))
+	if err := c.Start(s.DialOptions()...); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	if _, err := c.GetAccount(context.Background(), &core.Account{}); err != nil {
+		t.Fatal(err)
+	}
+	want := "a in,b in,c in,c out,b out,a out"
+	if got := strings.Join(order, ","); got != want {
+		t.Fatalf("got %s, want %s", got, want)
+	}
+}
+
+func TestInterceptorShortCircuit(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	reached := false
+	s.Handle("GetAccount", func(context.Context, any) (any, error) {
+		reached = true
+		return &core.Account{}, nil
+	})
+	denied := errors.New("denied")
+	c := client.New("bufnet", "")
+	c.Use(func(context.Context, *client.CallInfo, any, any, client.Invoker) error {
+		return denied
+	})
+	if err := c.Start(s.DialOptions()...); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	if _, err := c.GetAccount(context.Background(), &core.Account{}); !errors.Is(err, denied) || reached {
+		t.Fatalf("got %v, reached node %v", err, reached)
+	}
+}
+
+func TestLoggingInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c := flaky(t, client.LoggingInterceptor(logger))
+	callTwice(t, c)
+
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var r map[string]any
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		if r["method"] == getAccount {
+			records = append(records, r)
+		}
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records of %s, want 2:\n%s", len(records), getAccount, buf.String())
+	}
+	tests := []struct {
+		level, msg, code string
+	}{
+		{"DEBUG", "grpc call", "OK"},
+		{"WARN", "grpc call failed", "NotFound"},
+	}
+	for i, tt := range tests {
+		r := records[i]
+		if r["level"] != tt.level || r["msg"] != tt.msg || r["code"] != tt.code {
+			t.Errorf("record %d: got %v %v %v, want %s %s %s", i, r["level"], r["msg"], r["code"], tt.level, tt.msg, tt.code)
+		}
+		if r["node"] != "bufnet" || r["attempts"] != float64(1) {
+			t.Errorf("record %d: got node %v attempts %v", i, r["node"], r["attempts"])
+		}
+		if latency, ok := r["latency"].(float64); !ok || latency <= 0 {
+			t.Errorf("record %d: got latency %v", i, r["latency"])
+		}
+	}
+	if _, ok := records[0]["error"]; ok {
+		t.Error("successful call logged an error")
+	}
+	if e, _ := records[1]["error"].(string); !strings.Contains(e, "no account") {
+		t.Errorf("got error %q", e)
+	}
+}
+
+// sample returns the value of the metric line starting with prefix
+func sample(t *testing.T, text, prefix string) float64 {
+	t.Helper()
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, prefix+" ") {
+			v, err := strconv.ParseFloat(strings.TrimPrefix(line, prefix+" "), 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return v
+		}
+	}
+	t.Fatalf("no sample %s in:\n%s", prefix, text)
+	return 0
+}
+
+func TestMetrics(t *testing.T) {
+	m := client.NewMetrics()
+	c := flaky(t, client.MetricsInterceptor(m))
+	callTwice(t, c)
+
+	var buf bytes.Buffer
+	n, err := m.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteTo returned %d, %v for %d bytes", n, err, buf.Len())
+	}
+	text := buf.String()
+	for _, header := range []string{
+		"# TYPE tron_grpc_client_requests_total counter\n",
+		"# TYPE tron_grpc_client_request_duration_seconds histogram\n",
+	} {
+		if !strings.Contains(text, header) {
+			t.Errorf("missing %q", header)
+		}
+	}
+
+	labels := `method="` + getAccount + `",node="bufnet"`
+	if v := sample(t, text, `tron_grpc_client_requests_total{`+labels+`,code="OK"}`); v != 1 {
+		t.Errorf("got %v OK calls, want 1", v)
+	}
+	if v := sample(t, text, `tron_grpc_client_requests_total{`+labels+`,code="NotFound"}`); v != 1 {
+		t.Errorf("got %v NotFound calls, want 1", v)
+	}
+	if v := sample(t, text, `tron_grpc_client_request_duration_seconds_count{`+labels+`}`); v != 2 {
+		t.Errorf("got count %v, want 2", v)
+	}
+	if v := sample(t, text, `tron_grpc_client_request_duration_seconds_sum{`+labels+`}`); v <= 0 {
+		t.Errorf("got sum %v", v)
+	}
+	// buckets are cumulative and end at the count
+	prev := 0.0
+	for _, bound := range client.DefaultLatencyBuckets {
+		le := strconv.FormatFloat(bound, 'g', -1, 64)
+		v := sample(t, text, `tron_grpc_client_request_duration_seconds_bucket{`+labels+`,le="`+le+`"}`)
+		if v < prev {
+			t.Errorf("bucket %s: %v < %v", le, v, prev)
+		}
+		prev = v
+	}
+	if v := sample(t, text, `tron_grpc_client_request_duration_seconds_bucket{`+labels+`,le="+Inf"}`); v != 2 {
+		t.Errorf("got +Inf bucket %v, want 2", v)
+	}
+}
+
+func TestMetricsEscapeLabels(t *testing.T) {
+	m := client.NewMetrics()
+	m.ObserveCall("/a/B", "host\"1\\\n", "OK", 0)
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if v := sample(t, buf.String(), `tron_grpc_client_requests_total{method="/a/B",node="host\"1\\\n",code="OK"}`); v != 1 {
+		t.Fatalf("got %v", v)
+	}
+}
+
+type span struct {
+	name  string
+	attrs map[string]any
+	errs  []error
+	ended bool
+}
+
+func (s *span) SetAttribute(key string, value any) { s.attrs[key] = value }
+func (s *span) RecordError(err error)              { s.errs = append(s.errs, err) }
+func (s *span) End()                               { s.ended = true }
+
+type spanKey struct{}
+
+type tracer struct {
+	mu    sync.Mutex
+	spans []*span
+}
+
+func (tr *tracer) Start(ctx context.Context, name string) (context.Context, client.Span) {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	s := &span{name: name, attrs: make(map[string]any)}
+	tr.spans = append(tr.spans, s)
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func TestTracingInterceptor(t *testing.T) {
+	tr := new(tracer)
+	var inner []*span
+	c := flaky(t, client.TracingInterceptor(tr), func(ctx context.Context, info *client.CallInfo, req, reply any, next client.Invoker) error {
+		// the span travels in the context of the inner calls
+		s, _ := ctx.Value(spanKey{}).(*span)
+		if info.Method == getAccount {
+			inner = append(inner, s)
+		}
+		return next(ctx, info, req, reply)
+	})
+	callTwice(t, c)
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	var spans []*span
+	for _, s := range tr.spans {
+		if s.name == getAccount {
+			spans = append(spans, s)
+		}
+	}
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans of %s, want 2", len(spans), getAccount)
+	}
+	wantCodes := []codes.Code{codes.OK, codes.NotFound}
+	for i, s := range spans {
+		if !s.ended || inner[i] != s {
+			t.Errorf("span %d: ended %v, in context %v", i, s.ended, inner[i] == s)
+		}
+		want := map[string]any{
+			"rpc.system":           "grpc",
+			"rpc.method":           "GetAccount",
+			"net.peer.name":        "bufnet",
+			"tron.api_key":         "",
+			"rpc.attempts":         1,
+			"rpc.grpc.status_code": int(wantCodes[i]),
+		}
+		for k, v := range want {
+			if s.attrs[k] != v {
+				t.Errorf("span %d: %s is %v, want %v", i, k, s.attrs[k], v)
+			}
+		}
+	}
+	if len(spans[0].errs) != 0 || len(spans[1].errs) != 1 || status.Code(spans[1].errs[0]) != wantCodes[1] {
+		t.Errorf("got recorded errors %v and %v", spans[0].errs, spans[1].errs)
+	}
+}
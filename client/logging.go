@@ -0,0 +1,33 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/status"
+)
+
+// LoggingInterceptor logs every call with logger, successful calls at debug
+// level and failed calls at warn level
+func LoggingInterceptor(logger *slog.Logger) Interceptor {
+	return func(ctx context.Context, info *CallInfo, req, reply any, next Invoker) error {
+		begin := time.Now()
+		err := next(ctx, info, req, reply)
+		attrs := []slog.Attr{
+			slog.String("method", info.Method),
+			slog.String("node", info.Node),
+			slog.String("api_key", info.APIKeyID),
+			slog.Int("attempts", info.Attempts),
+			slog.Duration("latency", time.Since(begin)),
+			slog.String("code", status.Code(err).String()),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+			logger.LogAttrs(ctx, slog.LevelWarn, "grpc call failed", attrs...)
+		} else {
+			logger.LogAttrs(ctx, slog.LevelDebug, "grpc call", attrs...)
+		}
+		return err
+	}
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/status"
+)
+
+// MetricsRecorder receives one observation per call, it is small enough to
+// be backed by a prometheus CounterVec and HistogramVec
+type MetricsRecorder interface {
+	ObserveCall(method, node, code string, latency time.Duration)
+}
+
+// MetricsInterceptor reports every call to recorder
+func MetricsInterceptor(recorder MetricsRecorder) Interceptor {
+	return func(ctx context.Context, info *CallInfo, req, reply any, next Invoker) error {
+		begin := time.Now()
+		err := next(ctx, info, req, reply)
+		recorder.ObserveCall(info.Method, info.Node, status.Code(err).String(), time.Since(begin))
+		return err
+	}
+}
+
+// DefaultLatencyBuckets are the upper bounds in seconds of the latency histogram
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Metrics is an in-memory MetricsRecorder that keeps a request counter and
+// a latency histogram, and serves them in the prometheus text format
+type Metrics struct {
+	mu        sync.Mutex
+	buckets   []float64
+	requests  map[[3]string]uint64
+	latencies map[[2]string]*histogram
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		buckets:   DefaultLatencyBuckets,
+		requests:  make(map[[3]string]uint64),
+		latencies: make(map[[2]string]*histogram),
+	}
+}
+
+func (m *Metrics) ObserveCall(method, node, code string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests[[3]string{method, node, code}]++
+
+	key := [2]string{method, node}
+	h := m.latencies[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.latencies[key] = h
+	}
+	seconds := latency.Seconds()
+	for i, bound := range m.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// WriteTo writes the metrics in the prometheus text format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP tron_grpc_client_requests_total Number of grpc calls.\n")
+	b.WriteString("# TYPE tron_grpc_client_requests_total counter\n")
+	requestKeys := make([][3]string, 0, len(m.requests))
+	for k := range m.requests {
+		requestKeys = append(requestKeys, k)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		return strings.Join(requestKeys[i][:], "\x00") < strings.Join(requestKeys[j][:], "\x00")
+	})
+	for _, k := range requestKeys {
+		fmt.Fprintf(&b, "tron_grpc_client_requests_total{method=\"%s\",node=\"%s\",code=\"%s\"} %d\n",
+			escapeLabel(k[0]), escapeLabel(k[1]), escapeLabel(k[2]), m.requests[k])
+	}
+
+	b.WriteString("# HELP tron_grpc_client_request_duration_seconds Latency of grpc calls.\n")
+	b.WriteString("# TYPE tron_grpc_client_request_duration_seconds histogram\n")
+	latencyKeys := make([][2]string, 0, len(m.latencies))
+	for k := range m.latencies {
+		latencyKeys = append(latencyKeys, k)
+	}
+	sort.Slice(latencyKeys, func(i, j int) bool {
+		return strings.Join(latencyKeys[i][:], "\x00") < strings.Join(latencyKeys[j][:], "\x00")
+	})
+	for _, k := range latencyKeys {
+		h := m.latencies[k]
+		labels := fmt.Sprintf("method=\"%s\",node=\"%s\"", escapeLabel(k[0]), escapeLabel(k[1]))
+		for i, bound := range m.buckets {
+			fmt.Fprintf(&b, "tron_grpc_client_request_duration_seconds_bucket{%s,le=\"%g\"} %d\n", labels, bound, h.counts[i])
+		}
+		fmt.Fprintf(&b, "tron_grpc_client_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&b, "tron_grpc_client_request_duration_seconds_sum{%s} %g\n", labels, h.sum)
+		fmt.Fprintf(&b, "tron_grpc_client_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics to a prometheus scraper
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_, _ = m.WriteTo(w)
+}
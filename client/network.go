@@ -0,0 +1,51 @@
+package client
+
+import (
+	"fmt"
+	"time"
+)
+
+// Network is a named preset of endpoints and chain parameters
+type Network struct {
+	Name             string
+	Endpoints        []string
+	SolidityEndpoint string
+	// BlockInterval is the time between two blocks
+	BlockInterval time.Duration
+}
+
+var (
+	Mainnet = Network{
+		Name:             "mainnet",
+		Endpoints:        []string{"grpc.trongrid.io:50051"},
+		SolidityEndpoint: "grpc.trongrid.io:50052",
+		BlockInterval:    3 * time.Second,
+	}
+	Shasta = Network{
+		Name:             "shasta",
+		Endpoints:        []string{"grpc.shasta.trongrid.io:50051"},
+		SolidityEndpoint: "grpc.shasta.trongrid.io:50052",
+		BlockInterval:    3 * time.Second,
+	}
+	Nile = Network{
+		Name:             "nile",
+		Endpoints:        []string{"grpc.nile.trongrid.io:50051"},
+		SolidityEndpoint: "grpc.nile.trongrid.io:50061",
+		BlockInterval:    3 * time.Second,
+	}
+)
+
+var networks = map[string]Network{
+	Mainnet.Name: Mainnet,
+	Shasta.Name:  Shasta,
+	Nile.Name:    Nile,
+}
+
+// GetNetwork returns the preset of a network by name
+func GetNetwork(name string) (Network, error) {
+	n, ok := networks[name]
+	if !ok {
+		return Network{}, fmt.Errorf("unknown network %s", name)
+	}
+	return n, nil
+}
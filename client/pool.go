@@ -0,0 +1,374 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultMaxBlockLag         = 20
+	defaultBlockInterval       = 3 * time.Second
+	// defaultProbeTimeout bounds a probe when the client has no timeout
+	defaultProbeTimeout = 5 * time.Second
+)
+
+var ErrNoAvailableNode = fmt.Errorf("no available node")
+
+// Strategy decides which node serves a call
+type Strategy int
+
+const (
+	// RoundRobin rotates over the healthy nodes
+	RoundRobin Strategy = iota
+	// LeastLatency picks the healthy node with the lowest health check latency
+	LeastLatency
+)
+
+// NodeStatus is a snapshot of the health of one pool node
+type NodeStatus struct {
+	Address   string
+	Healthy   bool
+	Latency   time.Duration
+	HeadBlock int64
+	// HeadTime is the timestamp of the head block
+	HeadTime  time.Time
+	LastError error
+	CheckedAt time.Time
+}
+
+type node struct {
+	address string
+	conn    *grpc.ClientConn
+	wallet  api.WalletClient
+
+	mu     sync.RWMutex
+	status NodeStatus
+}
+
+func (n *node) getStatus() NodeStatus {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.status
+}
+
+func (n *node) markDown(err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.status.Healthy = false
+	n.status.LastError = err
+}
+
+// pool dispatches calls over several full nodes, it implements
+// grpc.ClientConnInterface so the generated api clients run on top of it
+type pool struct {
+	nodes    []*node
+	strategy Strategy
+	interval time.Duration
+	maxLag   int64
+	// blockInterval tells how old the head of a node may be, maxLag blocks
+	blockInterval time.Duration
+	// timeout returns the current timeout of the client, SetTimeout may
+	// change it after Start
+	timeout func() time.Duration
+	keys    *keyRing
+
+	next uint32
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func dialPool(addresses []string, opts []grpc.DialOption) (*pool, error) {
+	p := &pool{}
+	for _, addr := range addresses {
+		conn, err := grpc.Dial(addr, opts...)
+		if err != nil {
+			p.close()
+			return nil, fmt.Errorf("Connecting GRPC Client %s: %v", addr, err)
+		}
+		p.nodes = append(p.nodes, &node{
+			address: addr,
+			conn:    conn,
+			wallet:  api.NewWalletClient(conn),
+			status:  NodeStatus{Address: addr, Healthy: true},
+		})
+	}
+	return p, nil
+}
+
+func (p *pool) start() {
+	if p.interval <= 0 {
+		return
+	}
+	p.stop = make(chan struct{})
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(p.interval)
+		defer ticker.Stop()
+		p.checkHealth()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.checkHealth()
+			}
+		}
+	}()
+}
+
+func (p *pool) close() {
+	if p.stop != nil {
+		close(p.stop)
+		p.wg.Wait()
+		p.stop = nil
+	}
+	for _, n := range p.nodes {
+		n.conn.Close()
+	}
+}
+
+// waitReady blocks until one node of the pool is connected
+func (p *pool) waitReady(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ready := make(chan struct{}, len(p.nodes))
+	for _, n := range p.nodes {
+		go func(conn *grpc.ClientConn) {
+			conn.Connect()
+			for {
+				state := conn.GetState()
+				if state == connectivity.Ready {
+					ready <- struct{}{}
+					return
+				}
+				if !conn.WaitForStateChange(ctx, state) {
+					return
+				}
+			}
+		}(n.conn)
+	}
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// checkHealth probes every node with GetNowBlock2, nodes that fail or lag
+// more than maxLag blocks behind the best head are marked unhealthy. So is
+// a node whose head is older than maxLag blocks, even alone in the pool
+func (p *pool) checkHealth() {
+	var wg sync.WaitGroup
+	results := make([]NodeStatus, len(p.nodes))
+	for i, n := range p.nodes {
+		wg.Add(1)
+		go func(i int, n *node) {
+			defer wg.Done()
+			results[i] = p.probe(n)
+		}(i, n)
+	}
+	wg.Wait()
+
+	var best int64
+	for _, r := range results {
+		if r.LastError == nil && r.HeadBlock > best {
+			best = r.HeadBlock
+		}
+	}
+	for i, n := range p.nodes {
+		r := results[i]
+		r.Healthy = r.LastError == nil
+		if r.Healthy && best-r.HeadBlock > p.maxLag {
+			r.Healthy = false
+			r.LastError = fmt.Errorf("node is %d blocks behind", best-r.HeadBlock)
+		}
+		if age := r.CheckedAt.Sub(r.HeadTime); r.Healthy && p.blockInterval > 0 && age > time.Duration(p.maxLag)*p.blockInterval {
+			r.Healthy = false
+			r.LastError = fmt.Errorf("head block is %s old", age.Truncate(time.Second))
+		}
+		n.mu.Lock()
+		n.status = r
+		n.mu.Unlock()
+	}
+}
+
+// probe calls GetNowBlock2 on n, through the rate limits and the key
+// rotation like the other calls
+func (p *pool) probe(n *node) NodeStatus {
+	timeout := p.timeout()
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	var block *api.BlockExtention
+	var latency time.Duration
+	err := p.keys.do(ctx, ClassRead, true, func(ctx context.Context) error {
+		begin := time.Now()
+		var err error
+		block, err = n.wallet.GetNowBlock2(ctx, &api.EmptyMessage{})
+		latency = time.Since(begin)
+		return err
+	})
+	st := NodeStatus{
+		Address:   n.address,
+		Latency:   latency,
+		LastError: err,
+		CheckedAt: time.Now(),
+	}
+	if err == nil {
+		st.HeadBlock = block.GetBlockHeader().GetRawData().GetNumber()
+		st.HeadTime = time.UnixMilli(block.GetBlockHeader().GetRawData().GetTimestamp())
+	}
+	return st
+}
+
+// pick returns a node not in tried, preferring healthy ones
+func (p *pool) pick(tried map[*node]bool) *node {
+	var healthy, others []*node
+	for _, n := range p.nodes {
+		if tried[n] {
+			continue
+		}
+		if n.getStatus().Healthy {
+			healthy = append(healthy, n)
+		} else {
+			others = append(others, n)
+		}
+	}
+	candidates := healthy
+	if len(candidates) == 0 {
+		candidates = others
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if p.strategy == LeastLatency {
+		best := candidates[0]
+		for _, n := range candidates[1:] {
+			if n.getStatus().Latency < best.getStatus().Latency {
+				best = n
+			}
+		}
+		return best
+	}
+	idx := atomic.AddUint32(&p.next, 1)
+	return candidates[int(idx)%len(candidates)]
+}
+
+// shouldFailover tells whether a failed call is sent again to another
+// node. A broadcast is not, a node may have relayed it before failing
+func shouldFailover(method string, err error) bool {
+	return status.Code(err) == codes.Unavailable && methodName(method) != "BroadcastTransaction"
+}
+
+type excludeNodesCallOption struct {
+	grpc.EmptyCallOption
+	addresses []string
+}
+
+// ExcludeNodes makes a call skip the nodes at addresses, unless no other
+// node is left, e.g. to resend to another node
+func ExcludeNodes(addresses ...string) grpc.CallOption {
+	return excludeNodesCallOption{addresses: addresses}
+}
+
+type nodeUsedCallOption struct {
+	grpc.EmptyCallOption
+	address *string
+}
+
+// NodeUsed stores in address the node which served a call
+func NodeUsed(address *string) grpc.CallOption {
+	return nodeUsedCallOption{address: address}
+}
+
+// ExcludeNodes returns the ExcludeNodes call option, for the packages
+// seeing the Client through an interface
+func (c *Client) ExcludeNodes(addresses ...string) grpc.CallOption {
+	return ExcludeNodes(addresses...)
+}
+
+// NodeUsed returns the NodeUsed call option, for the packages seeing the
+// Client through an interface
+func (c *Client) NodeUsed(address *string) grpc.CallOption {
+	return NodeUsed(address)
+}
+
+// excluded returns the nodes excluded by opts, none if they exclude all of them
+func (p *pool) excluded(opts []grpc.CallOption) map[*node]bool {
+	tried := make(map[*node]bool)
+	for _, opt := range opts {
+		o, ok := opt.(excludeNodesCallOption)
+		if !ok {
+			continue
+		}
+		for _, n := range p.nodes {
+			for _, address := range o.addresses {
+				if n.address == address {
+					tried[n] = true
+				}
+			}
+		}
+	}
+	if len(tried) == len(p.nodes) {
+		return make(map[*node]bool)
+	}
+	return tried
+}
+
+func (p *pool) Invoke(ctx context.Context, method string, args any, reply any, opts ...grpc.CallOption) error {
+	tried := p.excluded(opts)
+	err := ErrNoAvailableNode
+	for {
+		n := p.pick(tried)
+		if n == nil {
+			return err
+		}
+		if info := getCallInfo(ctx); info != nil {
+			info.Node = n.address
+		}
+		for _, opt := range opts {
+			if o, ok := opt.(nodeUsedCallOption); ok && o.address != nil {
+				*o.address = n.address
+			}
+		}
+		err = n.conn.Invoke(ctx, method, args, reply, opts...)
+		if status.Code(err) == codes.Unavailable {
+			n.markDown(err)
+		}
+		if err == nil || !shouldFailover(method, err) || ctx.Err() != nil {
+			return err
+		}
+		tried[n] = true
+	}
+}
+
+func (p *pool) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	n := p.pick(nil)
+	if n == nil {
+		return nil, ErrNoAvailableNode
+	}
+	return n.conn.NewStream(ctx, desc, method, opts...)
+}
+
+func (p *pool) statuses() []NodeStatus {
+	ret := make([]NodeStatus, len(p.nodes))
+	for i, n := range p.nodes {
+		ret[i] = n.getStatus()
+	}
+	return ret
+}
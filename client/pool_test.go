@@ -0,0 +1,175 @@
+package client_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/client"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newPool(t *testing.T, servers map[string]*testutil.Server, endpoints ...string) *client.Client {
+	t.Helper()
+	c := client.NewWithEndpoints(endpoints, "")
+	c.SetStrategy(client.LeastLatency)
+	c.SetHealthCheck(0, 0)
+	if err := c.Start(testutil.PoolDialOptions(servers)...); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Stop)
+	return c
+}
+
+func TestFailoverSkipsBroadcast(t *testing.T) {
+	down, up := testutil.NewServer(), testutil.NewServer()
+	down.Stop()
+	defer up.Stop()
+	servers := map[string]*testutil.Server{"down": down, "up": up}
+
+	c := newPool(t, servers, "down", "up")
+	if _, err := c.GetNowBlock2(context.Background(), &api.EmptyMessage{}); err != nil {
+		t.Fatalf("read not failed over: %v", err)
+	}
+
+	c = newPool(t, servers, "down", "up")
+	_, err := c.BroadcastTransaction(context.Background(), &core.Transaction{}, client.WithRetry(nil))
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("broadcast failed over: %v", err)
+	}
+	if len(up.Pending()) != 0 {
+		t.Fatal("broadcast sent to another node")
+	}
+}
+
+func TestHealthCheckSingleLaggingNode(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	stale := time.Now().Add(-time.Hour).UnixMilli()
+	s.Handle("GetNowBlock2", func(context.Context, any) (any, error) {
+		return &api.BlockExtention{BlockHeader: &core.BlockHeader{RawData: &core.BlockHeaderRaw{Number: 1, Timestamp: stale}}}, nil
+	})
+	c := client.NewWithEndpoints([]string{"node"}, "")
+	c.SetHealthCheck(10*time.Millisecond, 20)
+	if err := c.Start(testutil.PoolDialOptions(map[string]*testutil.Server{"node": s})...); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		st := c.NodeStatuses()[0]
+		if !st.CheckedAt.IsZero() {
+			if st.Healthy || st.LastError == nil {
+				t.Fatalf("lagging node is healthy: %+v", st)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("single node never checked")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHealthCheckRateLimited(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	var mu sync.Mutex
+	probes := 0
+	s.Handle("GetNowBlock2", func(context.Context, any) (any, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		probes++
+		return &api.BlockExtention{BlockHeader: &core.BlockHeader{RawData: &core.BlockHeaderRaw{Timestamp: time.Now().UnixMilli()}}}, nil
+	})
+	c := client.NewWithEndpoints([]string{"node"}, "")
+	c.SetHealthCheck(time.Millisecond, 20)
+	c.SetRateLimit(&client.RateLimit{Rate: 10, Burst: 1})
+	begin := time.Now()
+	if err := c.Start(testutil.PoolDialOptions(map[string]*testutil.Server{"node": s})...); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	c.Stop()
+	elapsed := time.Since(begin)
+
+	mu.Lock()
+	defer mu.Unlock()
+	// 1 burst and 10/s, one probe every millisecond without the limit
+	if max := 2 + int(elapsed.Seconds()*10); probes < 1 || probes > max {
+		t.Fatalf("%d probes in %v, want at most %d, the rate limit is not applied", probes, elapsed, max)
+	}
+}
+
+// waitChecked waits for a health check of the node started after since
+func waitChecked(t *testing.T, c *client.Client, since time.Time) client.NodeStatus {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		st := c.NodeStatuses()[0]
+		if st.CheckedAt.After(since) {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("node never checked")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	var mu sync.Mutex
+	delay := time.Duration(0)
+	s.Handle("GetNowBlock2", func(ctx context.Context, req any) (any, error) {
+		mu.Lock()
+		d := delay
+		mu.Unlock()
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		head := &core.BlockHeaderRaw{Number: 1, Timestamp: time.Now().UnixMilli()}
+		return &api.BlockExtention{BlockHeader: &core.BlockHeader{RawData: head}}, nil
+	})
+	c := client.NewWithEndpoints([]string{"node"}, "")
+	// without timeout the probe uses a default one instead of failing
+	c.SetTimeout(0)
+	c.SetHealthCheck(10*time.Millisecond, 20)
+	if err := c.Start(testutil.PoolDialOptions(map[string]*testutil.Server{"node": s})...); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	if st := waitChecked(t, c, time.Time{}); !st.Healthy || st.LastError != nil {
+		t.Fatalf("node without timeout is unhealthy: %+v", st)
+	}
+
+	// the probe follows the timeout set after Start
+	mu.Lock()
+	delay = time.Minute
+	mu.Unlock()
+	c.SetTimeout(20 * time.Millisecond)
+	// a probe in flight may still see the old delay
+	since := time.Now()
+	for {
+		st := waitChecked(t, c, since)
+		if st.LastError != nil {
+			if st.Healthy || status.Code(st.LastError) != codes.DeadlineExceeded {
+				t.Fatalf("slow node with a short timeout: %+v", st)
+			}
+			return
+		}
+		if time.Since(since) > 2*time.Second {
+			t.Fatal("probe did not time out")
+		}
+		since = st.CheckedAt
+	}
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MethodClass groups rpc methods for rate limiting
+type MethodClass int
+
+const (
+	// ClassRead covers the read-only queries
+	ClassRead MethodClass = iota
+	// ClassWrite covers the calls that build transactions
+	ClassWrite
+	// ClassBroadcast covers BroadcastTransaction
+	ClassBroadcast
+)
+
+func getMethodClass(fullMethod string) MethodClass {
+	method := methodName(fullMethod)
+	if method == "BroadcastTransaction" {
+		return ClassBroadcast
+	}
+	if isReadOnly(method) {
+		return ClassRead
+	}
+	return ClassWrite
+}
+
+// RateLimit is a token bucket setting, Rate requests per second with bursts
+// up to Burst requests
+type RateLimit struct {
+	Rate  float64 `json:"rate" yaml:"rate"`
+	Burst int     `json:"burst" yaml:"burst"`
+}
+
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit *RateLimit) *tokenBucket {
+	if limit == nil || limit.Rate <= 0 {
+		return nil
+	}
+	burst := float64(limit.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   limit.Rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// wait takes one token, blocking until it is available or ctx is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	b.tokens--
+	var delay time.Duration
+	if b.tokens < 0 {
+		delay = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+	b.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// SetRateLimit limits the requests sent with each api key, nil removes the
+// limit. Must be called before Start
+func (c *Client) SetRateLimit(limit *RateLimit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rateLimit = limit
+}
+
+// SetMethodRateLimit limits the requests of a method class sent with each
+// api key, nil removes the limit. Must be called before Start
+func (c *Client) SetMethodRateLimit(class MethodClass, limit *RateLimit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.methodRateLimits == nil {
+		c.methodRateLimits = make(map[MethodClass]*RateLimit)
+	}
+	c.methodRateLimits[class] = limit
+}
@@ -0,0 +1,138 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy controls how failed read-only calls are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter randomizes each backoff by up to this fraction, between 0 and 1
+	Jitter float64
+	// Codes are the retryable status codes
+	Codes []codes.Code
+	// RetryBroadcast also retries BroadcastTransaction. Broadcasting the same
+	// signed transaction twice can not spend twice, but it is off by default
+	RetryBroadcast bool
+}
+
+// DefaultRetryPolicy retries transient errors and TronGrid rate limits
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    4,
+	InitialBackoff: 200 * time.Millisecond,
+	MaxBackoff:     3 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+	Codes:          []codes.Code{codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted},
+}
+
+var readOnlyPrefixes = []string{"Get", "List", "Scan", "Is", "Estimate", "TotalTransaction", "TriggerConstantContract"}
+
+type retryCallOption struct {
+	grpc.EmptyCallOption
+	policy *RetryPolicy
+}
+
+// WithRetry overrides the client retry policy for one call, nil disables retry
+func WithRetry(policy *RetryPolicy) grpc.CallOption {
+	return retryCallOption{policy: policy}
+}
+
+// SetRetryPolicy sets the retry policy for all calls of the client, nil disables retry
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) {
+	c.retryPolicy.Store(policy)
+}
+
+func methodName(fullMethod string) string {
+	return fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+}
+
+func isReadOnly(method string) bool {
+	for _, prefix := range readOnlyPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) getRetryPolicy(fullMethod string, opts []grpc.CallOption) *RetryPolicy {
+	policy := c.retryPolicy.Load()
+	for _, opt := range opts {
+		if o, ok := opt.(retryCallOption); ok {
+			policy = o.policy
+		}
+	}
+	if policy == nil || policy.MaxAttempts < 2 {
+		return nil
+	}
+	method := methodName(fullMethod)
+	if method == "BroadcastTransaction" {
+		if policy.RetryBroadcast {
+			return policy
+		}
+		return nil
+	}
+	if !isReadOnly(method) {
+		return nil
+	}
+	return policy
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	code := status.Code(err)
+	for _, c := range p.Codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff is the wait before the retry following attempt, the jitter never
+// takes it above MaxBackoff
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt))
+	if p.Jitter > 0 {
+		d = d * (1 + p.Jitter*(2*rand.Float64()-1))
+	}
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	return time.Duration(d)
+}
+
+func withRetry(ctx context.Context, policy *RetryPolicy, call func(context.Context) error) error {
+	if policy == nil {
+		return call(ctx)
+	}
+	var err error
+	for attempt := 0; attempt < policy.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			timer := time.NewTimer(policy.backoff(attempt - 1))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+		err = call(ctx)
+		if err == nil || ctx.Err() != nil || !policy.retryable(err) {
+			return err
+		}
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package client_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/client"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// failing returns a client on a node failing method with code, and the
+// count of the calls the node received. method must not be GetNowBlock2,
+// the health check calls it too
+func failing(t *testing.T, method string, code codes.Code) (*client.Client, *atomic.Int32) {
+	t.Helper()
+	s := testutil.NewServer()
+	t.Cleanup(s.Stop)
+	calls := new(atomic.Int32)
+	s.Handle(method, func(context.Context, any) (any, error) {
+		calls.Add(1)
+		return nil, status.Error(code, "failing")
+	})
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Stop)
+	return c, calls
+}
+
+func fastRetry(maxAttempts int) *client.RetryPolicy {
+	p := client.DefaultRetryPolicy
+	p.MaxAttempts = maxAttempts
+	p.InitialBackoff = time.Millisecond
+	p.MaxBackoff = time.Millisecond
+	return &p
+}
+
+func TestRetryReadOnly(t *testing.T) {
+	c, calls := failing(t, "GetAccount", codes.Unavailable)
+	c.SetRetryPolicy(fastRetry(3))
+	_, err := c.GetAccount(context.Background(), &core.Account{})
+	if status.Code(err) != codes.Unavailable || calls.Load() != 3 {
+		t.Fatalf("got %v after %d calls, want Unavailable after 3", err, calls.Load())
+	}
+
+	// the call option overrides the policy of the client
+	calls.Store(0)
+	_, err = c.GetAccount(context.Background(), &core.Account{}, client.WithRetry(fastRetry(5)))
+	if status.Code(err) != codes.Unavailable || calls.Load() != 5 {
+		t.Fatalf("WithRetry: got %v after %d calls, want 5", err, calls.Load())
+	}
+	calls.Store(0)
+	_, _ = c.GetAccount(context.Background(), &core.Account{}, client.WithRetry(nil))
+	if calls.Load() != 1 {
+		t.Fatalf("WithRetry(nil): %d calls, want 1", calls.Load())
+	}
+}
+
+func TestRetryStopsOnPermanentError(t *testing.T) {
+	for _, code := range []codes.Code{codes.InvalidArgument, codes.NotFound, codes.PermissionDenied, codes.Unimplemented} {
+		c, calls := failing(t, "GetAccount", code)
+		c.SetRetryPolicy(fastRetry(4))
+		_, err := c.GetAccount(context.Background(), &core.Account{})
+		if status.Code(err) != code || calls.Load() != 1 {
+			t.Fatalf("%s: got %v after %d calls, want 1 call", code, err, calls.Load())
+		}
+	}
+}
+
+func TestRetryBroadcastOnlyWhenAllowed(t *testing.T) {
+	c, calls := failing(t, "BroadcastTransaction", codes.Unavailable)
+	policy := fastRetry(3)
+	c.SetRetryPolicy(policy)
+	if _, err := c.BroadcastTransaction(context.Background(), &core.Transaction{}); err == nil || calls.Load() != 1 {
+		t.Fatalf("got %v after %d calls, want 1 call", err, calls.Load())
+	}
+
+	calls.Store(0)
+	policy.RetryBroadcast = true
+	if _, err := c.BroadcastTransaction(context.Background(), &core.Transaction{}); err == nil || calls.Load() != 3 {
+		t.Fatalf("RetryBroadcast: got %v after %d calls, want 3", err, calls.Load())
+	}
+}
+
+// the calls which change the chain, other than the broadcast, are never
+// retried
+func TestRetrySkipsWrites(t *testing.T) {
+	c, calls := failing(t, "CreateTransaction2", codes.Unavailable)
+	policy := fastRetry(3)
+	policy.RetryBroadcast = true
+	c.SetRetryPolicy(policy)
+	if _, err := c.CreateTransaction2(context.Background(), &core.TransferContract{}); err == nil || calls.Load() != 1 {
+		t.Fatalf("got %v after %d calls, want 1 call", err, calls.Load())
+	}
+}
+
+func TestRetryStopsOnCancel(t *testing.T) {
+	c, calls := failing(t, "GetAccount", codes.Unavailable)
+	policy := fastRetry(10)
+	policy.InitialBackoff = time.Hour
+	policy.MaxBackoff = time.Hour
+	c.SetRetryPolicy(policy)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.GetAccount(ctx, &core.Account{})
+	if status.Code(err) != codes.Unavailable || calls.Load() != 1 || time.Since(start) > 5*time.Second {
+		t.Fatalf("got %v after %d calls in %v", err, calls.Load(), time.Since(start))
+	}
+}
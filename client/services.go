@@ -0,0 +1,59 @@
+package client
+
+import "github.com/fullstackwang/tron-grpc/api"
+
+// SolidityClient wraps the WalletSolidity service, it only sees solidified data
+type SolidityClient struct {
+	c *Client
+}
+
+// ExtensionClient wraps the WalletExtension service
+type ExtensionClient struct {
+	c *Client
+}
+
+// DatabaseClient wraps the Database service
+type DatabaseClient struct {
+	c *Client
+}
+
+// MonitorClient wraps the Monitor service
+type MonitorClient struct {
+	c *Client
+}
+
+// NetworkClient wraps the Network service
+type NetworkClient struct {
+	c *Client
+}
+
+// Solidity returns the WalletSolidity service client
+func (c *Client) Solidity() *SolidityClient {
+	return &SolidityClient{c: c}
+}
+
+// WalletSolidity returns the WalletSolidity service client as the api
+// interface
+func (c *Client) WalletSolidity() api.WalletSolidityClient {
+	return c.Solidity()
+}
+
+// Extension returns the WalletExtension service client
+func (c *Client) Extension() *ExtensionClient {
+	return &ExtensionClient{c: c}
+}
+
+// Database returns the Database service client
+func (c *Client) Database() *DatabaseClient {
+	return &DatabaseClient{c: c}
+}
+
+// Monitor returns the Monitor service client
+func (c *Client) Monitor() *MonitorClient {
+	return &MonitorClient{c: c}
+}
+
+// Network returns the Network service client
+func (c *Client) Network() *NetworkClient {
+	return &NetworkClient{c: c}
+}
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"github.com/fullstackwang/tron-grpc/abi"
 	"github.com/fullstackwang/tron-grpc/address"
 	"github.com/fullstackwang/tron-grpc/core"
 )
@@ -10,4 +11,5 @@ type Signer interface {
 	PublicKey() []byte
 	SignTransaction(tx *core.Transaction) ([]byte, error)
 	SignMessage(msg string) ([]byte, error)
+	SignTypedData(td *abi.TypedData) ([]byte, error)
 }
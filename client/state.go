@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// drainTimeout bounds the wait for the calls in flight when a connection
+// is closed, the calls left are cut by closing the connections
+const drainTimeout = 10 * time.Second
+
+// connState holds everything built when the Client dials. It is replaced as
+// a whole by Start and Reconnect, calls hold a lease on it so the old state
+// is only closed once the calls in flight are done
+type connState struct {
+	mu           sync.Mutex
+	closed       bool
+	active       int
+	drained      chan struct{}
+	keys         *keyRing
+	pool         *pool
+	solidityPool *pool
+}
+
+func newConnState(keys *keyRing, p *pool) *connState {
+	return &connState{keys: keys, pool: p, drained: make(chan struct{})}
+}
+
+func (s *connState) acquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.active++
+	return true
+}
+
+func (s *connState) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active--
+	if s.closed && s.active == 0 {
+		close(s.drained)
+	}
+}
+
+// close refuses new calls and closes the connections once the calls in
+// flight are done, or after drainTimeout
+func (s *connState) close() {
+	s.mu.Lock()
+	s.closed = true
+	if s.active == 0 {
+		close(s.drained)
+	}
+	s.mu.Unlock()
+
+	timer := time.NewTimer(drainTimeout)
+	select {
+	case <-s.drained:
+	case <-timer.C:
+	}
+	timer.Stop()
+	s.pool.close()
+	if s.solidityPool != nil {
+		s.solidityPool.close()
+	}
+}
+
+func (s *connState) getPool(solidity bool) *pool {
+	if solidity && s.solidityPool != nil {
+		return s.solidityPool
+	}
+	return s.pool
+}
+
+func (s *connState) waitReady(ctx context.Context) error {
+	if err := s.pool.waitReady(ctx); err != nil {
+		return err
+	}
+	if s.solidityPool != nil {
+		return s.solidityPool.waitReady(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TLSConfig enables TLS, without it the transport is left to the dial
+// options and is insecure only when there are none. The roots
+// are RootCAs, else CAFile, else the system roots. A client certificate for
+// mutual TLS is Certificates, else CertFile and KeyFile
+type TLSConfig struct {
+	CAFile     string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+	CertFile   string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile    string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	ServerName string `json:"server_name,omitempty" yaml:"server_name,omitempty"`
+
+	RootCAs      *x509.CertPool    `json:"-" yaml:"-"`
+	Certificates []tls.Certificate `json:"-" yaml:"-"`
+}
+
+// Build returns the crypto/tls config
+func (cfg *TLSConfig) Build() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:   cfg.ServerName,
+		RootCAs:      cfg.RootCAs,
+		Certificates: cfg.Certificates,
+		MinVersion:   tls.VersionTLS12,
+	}
+	if tlsConfig.RootCAs == nil && cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if len(tlsConfig.Certificates) == 0 && (cfg.CertFile != "" || cfg.KeyFile != "") {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// SetTLS enables TLS for all endpoints, nil disables it. Must be called
+// before Start
+func (c *Client) SetTLS(cfg *TLSConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tls = cfg
+}
+
+// SetPerRPCCredentials attaches creds to every call, on top of the api key.
+// Must be called before Start
+func (c *Client) SetPerRPCCredentials(creds credentials.PerRPCCredentials) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.perRPCCreds = creds
+}
+
+// getDialOptions returns the dial options built from the client settings,
+// followed by the options given to Start which take precedence. Without
+// TLS nor options the connection is insecure, as it always was, otherwise
+// the options must set the transport credentials
+func (c *Client) getDialOptions() ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	if c.tls != nil {
+		tlsConfig, err := c.tls.Build()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	} else if len(c.opts) == 0 {
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if c.perRPCCreds != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(c.perRPCCreds))
+	}
+	return append(opts, c.opts...), nil
+}
@@ -0,0 +1,158 @@
+package client_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/client"
+	"github.com/fullstackwang/tron-grpc/core"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+type tlsWallet struct {
+	api.UnimplementedWalletServer
+}
+
+func (tlsWallet) GetNowBlock2(context.Context, *api.EmptyMessage) (*api.BlockExtention, error) {
+	return &api.BlockExtention{BlockHeader: &core.BlockHeader{RawData: &core.BlockHeaderRaw{Number: 7}}}, nil
+}
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return &testCA{cert: cert, key: key, pool: pool}
+}
+
+func (ca *testCA) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// serveMutualTLS starts a wallet server requiring a client certificate
+// signed by ca, it returns its address
+func serveMutualTLS(t *testing.T, ca *testCA) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{ca.issue(t, 2, x509.ExtKeyUsageServerAuth)},
+		ClientCAs:    ca.pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	})
+	server := grpc.NewServer(grpc.Creds(creds))
+	api.RegisterWalletServer(server, tlsWallet{})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+func TestMutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	addr := serveMutualTLS(t, ca)
+
+	c := client.New(addr, "")
+	c.SetTLS(&client.TLSConfig{
+		ServerName:   "localhost",
+		RootCAs:      ca.pool,
+		Certificates: []tls.Certificate{ca.issue(t, 3, x509.ExtKeyUsageClientAuth)},
+	})
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	block, err := c.GetNowBlock2(ctx, &api.EmptyMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := block.GetBlockHeader().GetRawData().GetNumber(); n != 7 {
+		t.Fatalf("block %d, want 7", n)
+	}
+}
+
+func TestMutualTLSWithoutClientCert(t *testing.T) {
+	ca := newTestCA(t)
+	addr := serveMutualTLS(t, ca)
+
+	c := client.New(addr, "")
+	c.SetTLS(&client.TLSConfig{ServerName: "localhost", RootCAs: ca.pool})
+	if err := c.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if _, err := c.GetNowBlock2(ctx, &api.EmptyMessage{}); err == nil {
+		t.Fatal("call succeeded without a client certificate")
+	}
+}
+
+// dial options without transport credentials are an error, not a silently
+// insecure connection
+func TestDialOptionsWithoutCredentials(t *testing.T) {
+	c := client.New("127.0.0.1:1", "")
+	defer c.Stop()
+	if err := c.Start(grpc.WithUserAgent("test")); err == nil {
+		t.Fatal("started without transport credentials")
+	}
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc/status"
+)
+
+// Tracer starts a span for each call. It mirrors the part of the
+// OpenTelemetry trace.Tracer the client needs, so an otel tracer only needs
+// a few lines of adapter and the module does not depend on otel
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is the part of an OpenTelemetry trace.Span used by the client
+type Span interface {
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// TracingInterceptor wraps every call in a span named after the rpc method
+func TracingInterceptor(tracer Tracer) Interceptor {
+	return func(ctx context.Context, info *CallInfo, req, reply any, next Invoker) error {
+		ctx, span := tracer.Start(ctx, info.Method)
+		defer span.End()
+		span.SetAttribute("rpc.system", "grpc")
+		span.SetAttribute("rpc.method", methodName(info.Method))
+
+		err := next(ctx, info, req, reply)
+		span.SetAttribute("net.peer.name", info.Node)
+		span.SetAttribute("tron.api_key", info.APIKeyID)
+		span.SetAttribute("rpc.attempts", info.Attempts)
+		span.SetAttribute("rpc.grpc.status_code", int(status.Code(err)))
+		if err != nil {
+			span.RecordError(err)
+		}
+		return err
+	}
+}
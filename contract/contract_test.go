@@ -0,0 +1,219 @@
+package contract_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/abi"
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/client"
+	"github.com/fullstackwang/tron-grpc/contract"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"github.com/fullstackwang/tron-grpc/tx"
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+var transferTopic = abi.GetKeccak256Hash([]byte("Transfer(address,address,uint256)"))
+
+func selector(sig string) string {
+	return string(abi.GetKeccak256Hash([]byte(sig))[:4])
+}
+
+// token is a fake TRC20 contract with name, decimals, balanceOf and
+// transfer
+type token struct {
+	addr     address.Address
+	mu       sync.Mutex
+	balances map[string]*big.Int
+}
+
+func (tk *token) balance(addr address.Address) *big.Int {
+	if b := tk.balances[string(addr)]; b != nil {
+		return b
+	}
+	return new(big.Int)
+}
+
+func (tk *token) handle(call *core.TriggerSmartContract) ([]byte, []*core.TransactionInfo_Log, error) {
+	tk.mu.Lock()
+	defer tk.mu.Unlock()
+	switch string(call.Data[:4]) {
+	case selector("name()"):
+		ret, err := abi.EncodeTypedData([]string{"string"}, []any{"Tether USD"})
+		return ret, nil, err
+	case selector("decimals()"):
+		ret, err := abi.EncodeTypedData([]string{"uint8"}, []any{big.NewInt(6)})
+		return ret, nil, err
+	case selector("balanceOf(address)"):
+		args, err := abi.DecodeTypedData([]string{"address"}, call.Data[4:])
+		if err != nil {
+			return nil, nil, err
+		}
+		ret, err := abi.EncodeTypedData([]string{"uint256"}, []any{tk.balance(args[0].(address.Address))})
+		return ret, nil, err
+	case selector("transfer(address,uint256)"):
+		args, err := abi.DecodeTypedData([]string{"address", "uint256"}, call.Data[4:])
+		if err != nil {
+			return nil, nil, err
+		}
+		from := address.Address(call.OwnerAddress)
+		to := args[0].(address.Address)
+		amount := args[1].(*big.Int)
+		if tk.balance(from).Cmp(amount) < 0 {
+			return nil, nil, &testutil.RevertError{}
+		}
+		tk.balances[string(from)] = new(big.Int).Sub(tk.balance(from), amount)
+		tk.balances[string(to)] = new(big.Int).Add(tk.balance(to), amount)
+		data, _ := abi.EncodeTypedData([]string{"uint256"}, []any{amount})
+		log := &core.TransactionInfo_Log{
+			Address: tk.addr.ToEthAddress(),
+			Topics: [][]byte{
+				transferTopic,
+				append(make([]byte, 12), from.ToEthAddress()...),
+				append(make([]byte, 12), to.ToEthAddress()...),
+			},
+			Data: data,
+		}
+		ret, err := abi.EncodeTypedData([]string{"bool"}, []any{true})
+		return ret, []*core.TransactionInfo_Log{log}, err
+	}
+	return nil, nil, &testutil.RevertError{}
+}
+
+func newToken(t *testing.T) (*testutil.Server, *client.Client, *token, *wallet.Wallet) {
+	t.Helper()
+	s := testutil.NewServer()
+	t.Cleanup(s.Stop)
+	s.AutoMine = true
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Stop)
+	owner, _ := wallet.Generate()
+	contractWallet, _ := wallet.Generate()
+	tk := &token{
+		addr:     contractWallet.Address(),
+		balances: map[string]*big.Int{string(owner.Address()): big.NewInt(100)},
+	}
+	s.SetBalance(owner.Address(), 1_000_000)
+	s.SetContract(tk.addr, tk.handle)
+	return s, c, tk, owner
+}
+
+func wait(t *testing.T, tt *tx.Transaction) error {
+	t.Helper()
+	return tt.WaitConfirmationContext(context.Background(), &tx.WaitOptions{PollInterval: time.Millisecond})
+}
+
+func TestErc20(t *testing.T) {
+	_, c, tk, owner := newToken(t)
+	to, _ := wallet.Generate()
+	ctx := context.Background()
+	erc20 := contract.NewErc20(c, tk.addr)
+	erc20.SetSigner(owner)
+
+	if name, err := erc20.Name(ctx); err != nil || name != "Tether USD" {
+		t.Fatalf("name %q %v", name, err)
+	}
+	if decimals, err := erc20.Decimals(ctx); err != nil || decimals != 6 {
+		t.Fatalf("decimals %d %v", decimals, err)
+	}
+
+	tt, err := erc20.Transfer(ctx, to.Address(), big.NewInt(30), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(t, tt); err != nil {
+		t.Fatal(err)
+	}
+	if fee := tt.GetRawData().GetFeeLimit(); fee != 50_000_000 {
+		t.Fatalf("fee limit %d", fee)
+	}
+	events, err := erc20.GetTransferEvents(tt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].From.String() != owner.Address().String() ||
+		events[0].To.String() != to.Address().String() || events[0].Value.Int64() != 30 {
+		t.Fatalf("events %v", events)
+	}
+	for addr, want := range map[*wallet.Wallet]int64{owner: 70, to: 30} {
+		if b, err := erc20.BalanceOf(ctx, addr.Address()); err != nil || b.Int64() != want {
+			t.Fatalf("balance of %s %v %v, want %d", addr.Address(), b, err, want)
+		}
+	}
+}
+
+func TestContract(t *testing.T) {
+	_, c, tk, owner := newToken(t)
+	to, _ := wallet.Generate()
+	ctx := context.Background()
+	ct := contract.New(c, tk.addr)
+	ct.Signer = owner
+	if err := ct.LoadABI([]byte(`[
+		{"type": "function", "name": "balanceOf", "stateMutability": "view",
+			"inputs": [{"name": "account", "type": "address"}],
+			"outputs": [{"name": "", "type": "uint256"}]},
+		{"type": "function", "name": "transfer", "stateMutability": "nonpayable",
+			"inputs": [{"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}],
+			"outputs": [{"name": "", "type": "bool"}]},
+		{"type": "event", "name": "Transfer", "inputs": [
+			{"name": "from", "type": "address", "indexed": true},
+			{"name": "to", "type": "address", "indexed": true},
+			{"name": "value", "type": "uint256", "indexed": false}]}
+	]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	tt, err := ct.Send(ctx, "transfer", to.Address(), big.NewInt(10), &contract.SendOption{FeeLimit: 1_000_000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wait(t, tt); err != nil {
+		t.Fatal(err)
+	}
+	if fee := tt.GetRawData().GetFeeLimit(); fee != 1_000_000 {
+		t.Fatalf("fee limit %d", fee)
+	}
+	ret, err := ct.GetResult(tt, "transfer")
+	if err != nil || len(ret) != 1 || ret[0] != true {
+		t.Fatalf("result %v %v", ret, err)
+	}
+	events, err := ct.GetEventsByName(tt, "Transfer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Inputs[2].Name != "value" || events[0].Inputs[2].Value.(*big.Int).Int64() != 10 {
+		t.Fatalf("events %+v", events)
+	}
+	ret, err = ct.Call(ctx, "balanceOf", to.Address())
+	if err != nil || ret[0].(*big.Int).Int64() != 10 {
+		t.Fatalf("balanceOf %v %v", ret, err)
+	}
+
+	// the revert of a sent call is reported by the confirmation
+	tt, err = ct.Send(ctx, "transfer", to.Address(), big.NewInt(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed *tx.FailedError
+	if err := wait(t, tt); !errors.As(err, &failed) || failed.Result != core.Transaction_Result_REVERT {
+		t.Fatalf("transfer above the balance: %v", err)
+	}
+
+	if _, err := ct.Call(ctx, "transfer", to.Address(), big.NewInt(1)); !errors.Is(err, contract.ErrMethodNotFound) {
+		t.Fatalf("calling a non constant method: %v", err)
+	}
+	if _, err := ct.Send(ctx, "mint"); !errors.Is(err, contract.ErrMethodNotFound) {
+		t.Fatalf("sending an unknown method: %v", err)
+	}
+	if _, err := ct.GetEventsByName(tt, "Approval"); !errors.Is(err, contract.ErrEventTypeNotFound) {
+		t.Fatalf("unknown event: %v", err)
+	}
+}
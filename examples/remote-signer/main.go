@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/fullstackwang/tron-grpc/remote"
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+// A local signing service holding the keys of a keystore directory, all
+// unlocked with the password of KEYSTORE_PASSWORD
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8545", "listen address")
+	dir := flag.String("keystore", "keystore", "keystore directory")
+	flag.Parse()
+
+	ks, err := wallet.NewKeyStore(*dir, nil)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	accounts, err := ks.Accounts()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	server := remote.NewServer()
+	server.Token = os.Getenv("SIGNER_TOKEN")
+	for _, addr := range accounts {
+		w, err := ks.Unlock(addr, os.Getenv("KEYSTORE_PASSWORD"))
+		if err != nil {
+			log.Fatalln(addr, err)
+		}
+		server.Add(w)
+		log.Println("serving", addr)
+	}
+	log.Fatalln(http.ListenAndServe(*listen, server))
+}
@@ -1,19 +1,21 @@
 module github.com/fullstackwang/tron-grpc
 
-go 1.19
+go 1.21
 
 require (
 	github.com/dustinxie/ecc v0.0.0-20210511000915-959544187564
 	github.com/golang/protobuf v1.5.2
+	github.com/miekg/pkcs11 v1.1.2
 	github.com/shengdoushi/base58 v1.0.0
 	golang.org/x/crypto v0.4.0
+	golang.org/x/text v0.5.0
 	google.golang.org/genproto v0.0.0-20221207170731-23e4bf6bdc37
 	google.golang.org/grpc v1.51.0
 	google.golang.org/protobuf v1.28.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	golang.org/x/net v0.3.0 // indirect
 	golang.org/x/sys v0.3.0 // indirect
-	golang.org/x/text v0.5.0 // indirect
 )
@@ -0,0 +1,358 @@
+//go:build cgo
+
+package hsm
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/fullstackwang/tron-grpc/abi"
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/wallet"
+	"github.com/miekg/pkcs11"
+)
+
+// oidSecp256k1 is the curve of the key, 1.3.132.0.10
+var oidSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+
+var ErrKeyNotFound = fmt.Errorf("key not found on token")
+
+// Config locates the module, the token and the key. The token is found by
+// TokenLabel, else the first token present. The key by KeyLabel and/or KeyID
+type Config struct {
+	Module     string
+	TokenLabel string
+	PIN        string
+	KeyLabel   string
+	KeyID      []byte
+}
+
+// module is a loaded PKCS#11 library. It is shared by the signers of the
+// process, Finalize ends every session on it so it runs with the last one
+type module struct {
+	ctx      *pkcs11.Ctx
+	refs     int
+	finalize bool
+}
+
+var (
+	modulesMu sync.Mutex
+	modules   = make(map[string]*module)
+)
+
+// loadModule returns the initialized module at path, it must be released
+func loadModule(path string) (*pkcs11.Ctx, error) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	if m := modules[path]; m != nil {
+		m.refs++
+		return m.ctx, nil
+	}
+	ctx := pkcs11.New(path)
+	if ctx == nil {
+		return nil, fmt.Errorf("cannot load pkcs11 module %s", path)
+	}
+	m := &module{ctx: ctx, refs: 1, finalize: true}
+	if err := ctx.Initialize(); err != nil {
+		if !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+			ctx.Destroy()
+			return nil, err
+		}
+		// initialized by someone else in the process, who finalizes it
+		m.finalize = false
+	}
+	modules[path] = m
+	return ctx, nil
+}
+
+// releaseModule unloads the module at path once no signer uses it
+func releaseModule(path string) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	m := modules[path]
+	if m == nil {
+		return
+	}
+	m.refs--
+	if m.refs > 0 {
+		return
+	}
+	delete(modules, path)
+	if m.finalize {
+		_ = m.ctx.Finalize()
+	}
+	m.ctx.Destroy()
+}
+
+// Signer signs with a private key which never leaves the PKCS#11 token.
+// It is safe for concurrent use, the calls share one session
+type Signer struct {
+	mu      sync.Mutex
+	module  string
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	key     pkcs11.ObjectHandle
+	pubKey  []byte
+	address address.Address
+}
+
+// open loads the module and logs into the token of cfg, the module is
+// released by close
+func open(cfg *Config) (*pkcs11.Ctx, pkcs11.SessionHandle, error) {
+	ctx, err := loadModule(cfg.Module)
+	if err != nil {
+		return nil, 0, err
+	}
+	fail := func(err error) (*pkcs11.Ctx, pkcs11.SessionHandle, error) {
+		releaseModule(cfg.Module)
+		return nil, 0, err
+	}
+
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return fail(err)
+	}
+	slot, found := uint(0), false
+	for _, s := range slots {
+		info, err := ctx.GetTokenInfo(s)
+		if err != nil {
+			return fail(err)
+		}
+		if cfg.TokenLabel == "" || info.Label == cfg.TokenLabel {
+			slot, found = s, true
+			break
+		}
+	}
+	if !found {
+		return fail(fmt.Errorf("token %q not found", cfg.TokenLabel))
+	}
+
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		return fail(err)
+	}
+	err = ctx.Login(session, pkcs11.CKU_USER, cfg.PIN)
+	if err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+		_ = ctx.CloseSession(session)
+		return fail(err)
+	}
+	return ctx, session, nil
+}
+
+// closeSession ends a session of open. There is no logout, the login is
+// shared by the sessions of the token and ends with the last one
+func closeSession(module string, ctx *pkcs11.Ctx, session pkcs11.SessionHandle) error {
+	err := ctx.CloseSession(session)
+	releaseModule(module)
+	return err
+}
+
+// New opens a session on the token and finds the key pair of cfg, the key
+// must be on the secp256k1 curve
+func New(cfg *Config) (*Signer, error) {
+	ctx, session, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s := &Signer{module: cfg.Module, ctx: ctx, session: session}
+	if err := s.load(cfg); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func keyTemplate(class uint, cfg *Config) []*pkcs11.Attribute {
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, class),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, pkcs11.CKK_EC),
+	}
+	if cfg.KeyLabel != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, cfg.KeyLabel))
+	}
+	if len(cfg.KeyID) > 0 {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, cfg.KeyID))
+	}
+	return template
+}
+
+func (s *Signer) findOne(template []*pkcs11.Attribute) (pkcs11.ObjectHandle, error) {
+	if err := s.ctx.FindObjectsInit(s.session, template); err != nil {
+		return 0, err
+	}
+	objs, _, err := s.ctx.FindObjects(s.session, 2)
+	if finalErr := s.ctx.FindObjectsFinal(s.session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	switch len(objs) {
+	case 0:
+		return 0, ErrKeyNotFound
+	case 1:
+		return objs[0], nil
+	}
+	return 0, fmt.Errorf("several keys match, set KeyLabel and KeyID")
+}
+
+func (s *Signer) load(cfg *Config) error {
+	var err error
+	s.key, err = s.findOne(keyTemplate(pkcs11.CKO_PRIVATE_KEY, cfg))
+	if err != nil {
+		return fmt.Errorf("private key: %w", err)
+	}
+	pub, err := s.findOne(keyTemplate(pkcs11.CKO_PUBLIC_KEY, cfg))
+	if err != nil {
+		return fmt.Errorf("public key: %w", err)
+	}
+	attrs, err := s.ctx.GetAttributeValue(s.session, pub, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+	})
+	if err != nil {
+		return err
+	}
+	var curve asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(attrs[0].Value, &curve); err != nil || !curve.Equal(oidSecp256k1) {
+		return fmt.Errorf("key is not on the secp256k1 curve")
+	}
+	s.pubKey, err = parseECPoint(attrs[1].Value)
+	if err != nil {
+		return err
+	}
+	s.address, err = address.FromPublicKey(s.pubKey)
+	return err
+}
+
+// parseECPoint reads CKA_EC_POINT, a DER octet string holding the
+// uncompressed point, some modules give the raw point
+func parseECPoint(data []byte) ([]byte, error) {
+	if len(data) == 65 && data[0] == 0x04 {
+		return data, nil
+	}
+	var point []byte
+	if _, err := asn1.Unmarshal(data, &point); err != nil {
+		return nil, fmt.Errorf("invalid CKA_EC_POINT: %w", err)
+	}
+	if len(point) != 65 || point[0] != 0x04 {
+		return nil, fmt.Errorf("public key is not an uncompressed point")
+	}
+	return point, nil
+}
+
+// Close ends the session, the module is unloaded with its last signer
+func (s *Signer) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx == nil {
+		return nil
+	}
+	err := closeSession(s.module, s.ctx, s.session)
+	s.ctx = nil
+	return err
+}
+
+func (s *Signer) Address() address.Address {
+	return s.address
+}
+
+func (s *Signer) PublicKey() []byte {
+	return s.pubKey
+}
+
+// SignHash signs a 32 byte hash on the token, v is 0 or 1
+func (s *Signer) SignHash(hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, fmt.Errorf("hash must be 32 bytes")
+	}
+	s.mu.Lock()
+	if s.ctx == nil {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("signer closed")
+	}
+	err := s.ctx.SignInit(s.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_ECDSA, nil)}, s.key)
+	var sig []byte
+	if err == nil {
+		sig, err = s.ctx.Sign(s.session, hash)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return NormalizeSignature(hash, sig, s.pubKey)
+}
+
+func (s *Signer) SignTransaction(tx *core.Transaction) ([]byte, error) {
+	hash, err := wallet.TransactionHash(tx)
+	if err != nil {
+		return nil, err
+	}
+	return s.SignHash(hash)
+}
+
+func (s *Signer) SignMessage(msg string) ([]byte, error) {
+	return s.SignHash(wallet.MessageHash([]byte(msg), wallet.MessageFormatTron))
+}
+
+// SignTypedData signs the TIP-712 hash of td, v is 27 or 28 like
+// wallet.Wallet
+func (s *Signer) SignTypedData(td *abi.TypedData) ([]byte, error) {
+	hash, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.SignHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// GenerateKey creates a secp256k1 key pair on the token of cfg, labelled
+// with cfg.KeyLabel and cfg.KeyID. The private key is not extractable
+func GenerateKey(cfg *Config) (address.Address, error) {
+	ctx, session, err := open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSession(cfg.Module, ctx, session)
+
+	params, err := asn1.Marshal(oidSecp256k1)
+	if err != nil {
+		return nil, err
+	}
+	public := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, cfg.KeyLabel),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, cfg.KeyID),
+	}
+	private := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+		pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+		pkcs11.NewAttribute(pkcs11.CKA_EXTRACTABLE, false),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, cfg.KeyLabel),
+		pkcs11.NewAttribute(pkcs11.CKA_ID, cfg.KeyID),
+	}
+	pub, _, err := ctx.GenerateKeyPair(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(pkcs11.CKM_EC_KEY_PAIR_GEN, nil)}, public, private)
+	if err != nil {
+		return nil, err
+	}
+	attrs, err := ctx.GetAttributeValue(session, pub, []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil)})
+	if err != nil {
+		return nil, err
+	}
+	point, err := parseECPoint(attrs[0].Value)
+	if err != nil {
+		return nil, err
+	}
+	return address.FromPublicKey(point)
+}
@@ -0,0 +1,100 @@
+//go:build cgo
+
+package hsm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+var softhsmPaths = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+	"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+}
+
+// softhsm returns a Config on a fresh SoftHSM token, the test is skipped
+// without SoftHSM. SOFTHSM2_MODULE overrides the module path
+func softhsm(t *testing.T) *Config {
+	t.Helper()
+	module := os.Getenv("SOFTHSM2_MODULE")
+	if module == "" {
+		for _, path := range softhsmPaths {
+			if _, err := os.Stat(path); err == nil {
+				module = path
+				break
+			}
+		}
+	}
+	if module == "" {
+		t.Skip("SoftHSM module not found, set SOFTHSM2_MODULE")
+	}
+	util, err := exec.LookPath("softhsm2-util")
+	if err != nil {
+		t.Skip("softhsm2-util not found")
+	}
+
+	dir := t.TempDir()
+	conf := filepath.Join(dir, "softhsm2.conf")
+	tokens := filepath.Join(dir, "tokens")
+	if err := os.Mkdir(tokens, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(conf, []byte("directories.tokendir = "+tokens+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOFTHSM2_CONF", conf)
+	out, err := exec.Command(util, "--init-token", "--free", "--label", "tron", "--pin", "1234", "--so-pin", "1234").CombinedOutput()
+	if err != nil {
+		t.Fatalf("init token: %v: %s", err, out)
+	}
+	return &Config{Module: module, TokenLabel: "tron", PIN: "1234", KeyLabel: "test"}
+}
+
+func TestSignerSharedModule(t *testing.T) {
+	cfg := softhsm(t)
+	addr, err := GenerateKey(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+	if !bytes.Equal(first.Address(), addr) || !bytes.Equal(second.Address(), addr) {
+		t.Fatalf("signer addresses %s %s, want %s", first.Address(), second.Address(), addr)
+	}
+
+	// closing one signer must leave the session of the other usable
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256([]byte("tron"))
+	sig, err := second.SignHash(hash[:])
+	if err != nil {
+		t.Fatalf("sign after closing the other signer: %v", err)
+	}
+	pub, err := wallet.RecoverPubKey(hash[:], sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pub, second.PublicKey()) {
+		t.Fatal("signature does not recover to the token key")
+	}
+	if _, err := first.SignHash(hash[:]); err == nil {
+		t.Fatal("closed signer still signs")
+	}
+}
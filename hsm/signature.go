@@ -0,0 +1,68 @@
+// Package hsm implements client.Signer with secp256k1 keys held in a
+// PKCS#11 module, such as a network HSM or SoftHSM for tests:
+//
+//	softhsm2-util --init-token --free --label tron --pin 1234 --so-pin 1234
+//
+//	cfg := &hsm.Config{Module: "/usr/lib/softhsm/libsofthsm2.so", TokenLabel: "tron", PIN: "1234", KeyLabel: "treasury"}
+//	hsm.GenerateKey(cfg)
+//	signer, err := hsm.New(cfg)
+//
+// The PKCS#11 signer needs cgo, NormalizeSignature does not
+package hsm
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/dustinxie/ecc"
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+var ErrRecovery = fmt.Errorf("signature does not recover to the public key")
+
+type derSignature struct {
+	R, S *big.Int
+}
+
+// NormalizeSignature converts an ECDSA signature of hash, DER encoded or
+// raw r||s, into the 65 byte r||s||v form of TRON. s is made low and v is
+// found by recovering the public key pub, which is uncompressed
+func NormalizeSignature(hash, sig, pub []byte) ([]byte, error) {
+	var r, s *big.Int
+	if len(sig) == 64 {
+		r = new(big.Int).SetBytes(sig[:32])
+		s = new(big.Int).SetBytes(sig[32:])
+	} else {
+		var der derSignature
+		rest, err := asn1.Unmarshal(sig, &der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DER signature: %w", err)
+		}
+		if len(rest) > 0 {
+			return nil, fmt.Errorf("invalid DER signature: trailing data")
+		}
+		r, s = der.R, der.S
+	}
+
+	n := ecc.P256k1().Params().N
+	if r.Sign() <= 0 || s.Sign() <= 0 || r.Cmp(n) >= 0 || s.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("signature out of range")
+	}
+	if s.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		s = new(big.Int).Sub(n, s)
+	}
+
+	out := make([]byte, 65)
+	r.FillBytes(out[:32])
+	s.FillBytes(out[32:64])
+	for v := byte(0); v < 2; v++ {
+		out[64] = v
+		recovered, err := wallet.RecoverPubKey(hash, out)
+		if err == nil && bytes.Equal(recovered, pub) {
+			return out, nil
+		}
+	}
+	return nil, ErrRecovery
+}
@@ -0,0 +1,98 @@
+package hsm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/dustinxie/ecc"
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+func derEncode(t *testing.T, r, s *big.Int) []byte {
+	t.Helper()
+	der, err := asn1.Marshal(derSignature{R: r, S: s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestNormalizeSignature(t *testing.T) {
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := ecc.P256k1().Params().N
+
+	// sign until both recovery ids are seen
+	seen := map[byte]bool{}
+	for i := 0; len(seen) < 2 && i < 64; i++ {
+		hash := sha256.Sum256([]byte{byte(i)})
+		want, err := w.SignHash(hash[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[want[64]] = true
+
+		r := new(big.Int).SetBytes(want[:32])
+		s := new(big.Int).SetBytes(want[32:64])
+		highS := new(big.Int).Sub(n, s)
+		raw := make([]byte, 64)
+		r.FillBytes(raw[:32])
+		highS.FillBytes(raw[32:])
+
+		for name, sig := range map[string][]byte{
+			"der":        derEncode(t, r, s),
+			"der high s": derEncode(t, r, highS),
+			"raw":        want[:64],
+			"raw high s": raw,
+		} {
+			got, err := NormalizeSignature(hash[:], sig, w.PublicKey())
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("%s: got %x, want %x", name, got, want)
+			}
+		}
+	}
+	if len(seen) < 2 {
+		t.Fatalf("recovery ids seen %v, want 0 and 1", seen)
+	}
+}
+
+func TestNormalizeSignatureErrors(t *testing.T) {
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256([]byte("tron"))
+	sig, err := w.SignHash(hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+
+	if _, err := NormalizeSignature(hash[:], sig[:64], other.PublicKey()); !errors.Is(err, ErrRecovery) {
+		t.Fatalf("wrong key: got %v, want ErrRecovery", err)
+	}
+	der := append(derEncode(t, r, s), 0)
+	if _, err := NormalizeSignature(hash[:], der, w.PublicKey()); err == nil {
+		t.Fatal("trailing data accepted")
+	}
+	n := ecc.P256k1().Params().N
+	for _, bad := range []*big.Int{big.NewInt(0), n} {
+		if _, err := NormalizeSignature(hash[:], derEncode(t, bad, s), w.PublicKey()); err == nil {
+			t.Fatalf("r = %v accepted", bad)
+		}
+	}
+}
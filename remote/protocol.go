@@ -0,0 +1,70 @@
+// Package remote implements client.Signer over HTTP, so keys can be kept in
+// a separate signing process.
+//
+// The service contract is json over HTTP, bytes are hex strings without 0x
+// and addresses are base58:
+//
+//	GET  /v1/accounts
+//	     -> {"accounts": [{"address": "T...", "public_key": "04..."}]}
+//	POST /v1/sign
+//	     {"address": "T...", "hash": "<32 bytes>", "kind": "transaction", "raw_data": "..."}
+//	     -> {"signature": "<65 bytes r||s||v, v is 0 or 1>"}
+//
+// The signer only ever signs hashes, kind and raw_data tell the server what
+// the hash is for so it can apply a policy. kind is one of transaction,
+// message and typed_data, raw_data is the preimage of the hash: the
+// serialized core.TransactionRaw of a transaction, the bytes of a message
+// hashed in the Tron format, or the json of TIP-712 typed data. It is
+// required, the server hashes it again and rejects a different hash.
+//
+// Errors are any non 2xx status with {"error": "..."}. A bearer token is
+// sent in the Authorization header when configured
+package remote
+
+import "fmt"
+
+const (
+	AccountsPath = "/v1/accounts"
+	SignPath     = "/v1/sign"
+)
+
+// Kind tells what a signed hash is for
+type Kind string
+
+const (
+	KindTransaction Kind = "transaction"
+	KindMessage     Kind = "message"
+	KindTypedData   Kind = "typed_data"
+)
+
+var (
+	ErrUnauthorized   = fmt.Errorf("unauthorized")
+	ErrUnknownAccount = fmt.Errorf("unknown account")
+	ErrHashMismatch   = fmt.Errorf("hash does not match raw_data")
+	ErrMissingRawData = fmt.Errorf("raw_data is required")
+)
+
+// Account is a key held by the server
+type Account struct {
+	Address   string `json:"address"`
+	PublicKey string `json:"public_key"`
+}
+
+type AccountsResponse struct {
+	Accounts []Account `json:"accounts"`
+}
+
+type SignRequest struct {
+	Address string `json:"address"`
+	Hash    string `json:"hash"`
+	Kind    Kind   `json:"kind"`
+	RawData string `json:"raw_data"`
+}
+
+type SignResponse struct {
+	Signature string `json:"signature"`
+}
+
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
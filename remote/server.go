@@ -0,0 +1,190 @@
+package remote
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/fullstackwang/tron-grpc/abi"
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/wallet"
+	"github.com/golang/protobuf/proto"
+)
+
+// HashSigner is a key able to sign raw hashes, like *wallet.Wallet
+type HashSigner interface {
+	Address() address.Address
+	PublicKey() []byte
+	SignHash(hash []byte) ([]byte, error)
+}
+
+// PolicyFunc approves a sign request, an error rejects it with 403. The
+// hash has already been checked to be the one of raw_data for its kind
+type PolicyFunc func(addr address.Address, req *SignRequest) error
+
+// Server is the reference signing service, an http.Handler
+type Server struct {
+	// Token is the bearer token required from clients, empty to disable
+	Token string
+	// Policy approves each request, nil approves all
+	Policy PolicyFunc
+
+	mu      sync.RWMutex
+	signers map[string]HashSigner
+	mux     *http.ServeMux
+}
+
+// NewServer creates a Server holding signers
+func NewServer(signers ...HashSigner) *Server {
+	s := &Server{signers: make(map[string]HashSigner), mux: http.NewServeMux()}
+	for _, signer := range signers {
+		s.Add(signer)
+	}
+	s.mux.HandleFunc(AccountsPath, s.handleAccounts)
+	s.mux.HandleFunc(SignPath, s.handleSign)
+	return s
+}
+
+// Add adds a key to the server
+func (s *Server) Add(signer HashSigner) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signers[string(signer.Address())] = signer
+}
+
+// Remove removes the key of addr
+func (s *Server) Remove(addr address.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.signers, string(addr))
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Token != "" {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+			writeError(w, http.StatusUnauthorized, ErrUnauthorized)
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	s.mu.RLock()
+	resp := AccountsResponse{Accounts: make([]Account, 0, len(s.signers))}
+	for _, signer := range s.signers {
+		resp.Accounts = append(resp.Accounts, Account{
+			Address:   signer.Address().String(),
+			PublicKey: hex.EncodeToString(signer.PublicKey()),
+		})
+	}
+	s.mu.RUnlock()
+	writeJSON(w, http.StatusOK, &resp)
+}
+
+func (s *Server) handleSign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	var req SignRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	addr, err := address.FromBase58(req.Address)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	hash, err := hex.DecodeString(req.Hash)
+	if err != nil || len(hash) != 32 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("hash must be 32 hex bytes"))
+		return
+	}
+	sum, err := preimageHash(req.Kind, req.RawData)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !bytes.Equal(sum, hash) {
+		writeError(w, http.StatusBadRequest, ErrHashMismatch)
+		return
+	}
+
+	s.mu.RLock()
+	signer := s.signers[string(addr)]
+	s.mu.RUnlock()
+	if signer == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", ErrUnknownAccount, req.Address))
+		return
+	}
+	if s.Policy != nil {
+		if err := s.Policy(addr, &req); err != nil {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+	}
+	sig, err := signer.SignHash(hash)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, &SignResponse{Signature: hex.EncodeToString(sig)})
+}
+
+// preimageHash hashes the hex rawData the way kind is signed
+func preimageHash(kind Kind, rawData string) ([]byte, error) {
+	preimage, err := hex.DecodeString(rawData)
+	if err != nil {
+		return nil, fmt.Errorf("raw_data: %w", err)
+	}
+	if len(preimage) == 0 {
+		return nil, ErrMissingRawData
+	}
+	switch kind {
+	case KindTransaction:
+		var raw core.TransactionRaw
+		if err := proto.Unmarshal(preimage, &raw); err != nil {
+			return nil, fmt.Errorf("raw_data: %w", err)
+		}
+		sum := sha256.Sum256(preimage)
+		return sum[:], nil
+	case KindMessage:
+		return wallet.MessageHash(preimage, wallet.MessageFormatTron), nil
+	case KindTypedData:
+		td, err := abi.ParseTypedData(preimage)
+		if err != nil {
+			return nil, fmt.Errorf("raw_data: %w", err)
+		}
+		return td.Hash()
+	}
+	return nil, fmt.Errorf("unknown kind %q", kind)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		code = http.StatusRequestEntityTooLarge
+	}
+	writeJSON(w, code, &ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,136 @@
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fullstackwang/tron-grpc/abi"
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/wallet"
+	"github.com/golang/protobuf/proto"
+)
+
+func newTestServer(t *testing.T) (*wallet.Wallet, *Server, *httptest.Server) {
+	t.Helper()
+	w, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(w)
+	hs := httptest.NewServer(s)
+	t.Cleanup(hs.Close)
+	return w, s, hs
+}
+
+func signRequest(t *testing.T, url string, req *SignRequest) (int, string) {
+	t.Helper()
+	var resp SignResponse
+	err := newService(url, nil).do(context.Background(), http.MethodPost, SignPath, req, &resp)
+	if err != nil {
+		return http.StatusBadRequest, err.Error()
+	}
+	return http.StatusOK, resp.Signature
+}
+
+func TestSignRequiresPreimage(t *testing.T) {
+	w, s, hs := newTestServer(t)
+	transfers := 0
+	s.Policy = func(_ address.Address, req *SignRequest) error {
+		if req.Kind == KindTransaction {
+			transfers++
+		}
+		return nil
+	}
+
+	raw := &core.TransactionRaw{Timestamp: 1, Expiration: 2}
+	rawData, err := proto.Marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := sha256.Sum256(rawData)
+	hash := hex.EncodeToString(txid[:])
+
+	bypasses := map[string]*SignRequest{
+		"transaction without raw_data": {Address: w.Address().String(), Hash: hash, Kind: KindTransaction},
+		"txid as a message":            {Address: w.Address().String(), Hash: hash, Kind: KindMessage},
+		"txid as a message with its raw_data": {
+			Address: w.Address().String(), Hash: hash, Kind: KindMessage, RawData: hex.EncodeToString(rawData),
+		},
+		"txid as typed data": {Address: w.Address().String(), Hash: hash, Kind: KindTypedData, RawData: hex.EncodeToString([]byte("{}"))},
+		"unknown kind":       {Address: w.Address().String(), Hash: hash, Kind: "blob", RawData: hex.EncodeToString(rawData)},
+	}
+	for name, req := range bypasses {
+		if code, msg := signRequest(t, hs.URL, req); code == http.StatusOK {
+			t.Errorf("%s: signed", name)
+		} else if !strings.Contains(msg, "400") {
+			t.Errorf("%s: %s", name, msg)
+		}
+	}
+
+	req := &SignRequest{Address: w.Address().String(), Hash: hash, Kind: KindTransaction, RawData: hex.EncodeToString(rawData)}
+	if code, msg := signRequest(t, hs.URL, req); code != http.StatusOK {
+		t.Fatal(msg)
+	}
+	if transfers != 1 {
+		t.Fatalf("policy saw %d transactions, want 1", transfers)
+	}
+}
+
+func TestSignerRoundTrip(t *testing.T) {
+	w, _, hs := newTestServer(t)
+	signer, err := NewSigner(context.Background(), hs.URL, w.Address(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := &core.Transaction{RawData: &core.TransactionRaw{Timestamp: 1, Expiration: 2}}
+	sig, err := signer.SignTransaction(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Signature = [][]byte{sig}
+	if !wallet.VerifyTransaction(tx, w.Address()) {
+		t.Fatal("bad transaction signature")
+	}
+
+	sig, err = signer.SignMessage("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !wallet.VerifyMessage(w.Address(), "hello", sig) {
+		t.Fatal("bad message signature")
+	}
+
+	td, err := abi.ParseTypedData([]byte(`{
+		"types": {"Mail": [{"name": "contents", "type": "string"}]},
+		"primaryType": "Mail",
+		"domain": {"name": "test", "version": "1", "chainId": 1},
+		"message": {"contents": "hi"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err = signer.SignTypedData(td)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !wallet.VerifyTypedData(w.Address(), td, sig) {
+		t.Fatal("bad typed data signature")
+	}
+}
+
+func TestSignUnknownAccount(t *testing.T) {
+	_, _, hs := newTestServer(t)
+	other, _ := wallet.Generate()
+	_, err := NewSigner(context.Background(), hs.URL, other.Address(), nil)
+	if !errors.Is(err, ErrUnknownAccount) {
+		t.Fatal(err)
+	}
+}
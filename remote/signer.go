@@ -0,0 +1,219 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/abi"
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/wallet"
+	"github.com/golang/protobuf/proto"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Options sets how the signing service is reached, nil is the default
+type Options struct {
+	// Token is sent as a bearer token
+	Token string
+	// HTTPClient is used for the requests, set its transport for TLS
+	HTTPClient *http.Client
+	// Timeout bounds each request, 10s by default
+	Timeout time.Duration
+}
+
+type service struct {
+	url     string
+	token   string
+	client  *http.Client
+	timeout time.Duration
+}
+
+func newService(baseURL string, opts *Options) *service {
+	if opts == nil {
+		opts = &Options{}
+	}
+	s := &service{
+		url:     strings.TrimRight(baseURL, "/"),
+		token:   opts.Token,
+		client:  opts.HTTPClient,
+		timeout: opts.Timeout,
+	}
+	if s.client == nil {
+		s.client = http.DefaultClient
+	}
+	if s.timeout <= 0 {
+		s.timeout = defaultTimeout
+	}
+	return s
+}
+
+func (s *service) do(ctx context.Context, method, path string, in, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.url+path, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		var e ErrorResponse
+		_ = json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&e)
+		switch resp.StatusCode {
+		case http.StatusUnauthorized:
+			return ErrUnauthorized
+		case http.StatusNotFound:
+			return fmt.Errorf("%w: %s", ErrUnknownAccount, e.Error)
+		}
+		return fmt.Errorf("remote signer: %s: %s", resp.Status, e.Error)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Accounts lists the accounts of the signing service at baseURL
+func Accounts(ctx context.Context, baseURL string, opts *Options) ([]Account, error) {
+	var resp AccountsResponse
+	err := newService(baseURL, opts).do(ctx, http.MethodGet, AccountsPath, nil, &resp)
+	return resp.Accounts, err
+}
+
+// Signer is a client.Signer whose key is held by a signing service
+type Signer struct {
+	service *service
+	address address.Address
+	pubKey  []byte
+}
+
+// NewSigner returns the signer of addr on the service at baseURL, it fails
+// if the service does not hold the key
+func NewSigner(ctx context.Context, baseURL string, addr address.Address, opts *Options) (*Signer, error) {
+	s := newService(baseURL, opts)
+	var resp AccountsResponse
+	if err := s.do(ctx, http.MethodGet, AccountsPath, nil, &resp); err != nil {
+		return nil, err
+	}
+	for _, acc := range resp.Accounts {
+		if acc.Address != addr.String() {
+			continue
+		}
+		pub, err := hex.DecodeString(acc.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		if pubAddr, err := address.FromPublicKey(pub); err != nil || !bytes.Equal(pubAddr, addr) {
+			return nil, fmt.Errorf("public key of %s does not match its address", addr)
+		}
+		return &Signer{service: s, address: addr, pubKey: pub}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, addr)
+}
+
+func (s *Signer) Address() address.Address {
+	return s.address
+}
+
+func (s *Signer) PublicKey() []byte {
+	return s.pubKey
+}
+
+// SignHash has the service sign hash, rawData is its preimage for kind. The
+// signature is checked against the address before it is returned
+func (s *Signer) SignHash(ctx context.Context, hash []byte, kind Kind, rawData []byte) ([]byte, error) {
+	req := SignRequest{
+		Address: s.address.String(),
+		Hash:    hex.EncodeToString(hash),
+		Kind:    kind,
+		RawData: hex.EncodeToString(rawData),
+	}
+	var resp SignResponse
+	if err := s.service.do(ctx, http.MethodPost, SignPath, &req, &resp); err != nil {
+		return nil, err
+	}
+	sig, err := hex.DecodeString(resp.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if len(sig) == 65 && sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	signer, err := wallet.RecoverAddress(hash, sig)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(signer, s.address) {
+		return nil, fmt.Errorf("remote signer returned a signature of %s instead of %s", signer, s.address)
+	}
+	return sig, nil
+}
+
+func (s *Signer) SignTransaction(tx *core.Transaction) ([]byte, error) {
+	rawData, err := proto.Marshal(tx.GetRawData())
+	if err != nil {
+		return nil, err
+	}
+	hash, err := wallet.TransactionHash(tx)
+	if err != nil {
+		return nil, err
+	}
+	return s.SignHash(context.Background(), hash, KindTransaction, rawData)
+}
+
+func (s *Signer) SignMessage(msg string) ([]byte, error) {
+	hash := wallet.MessageHash([]byte(msg), wallet.MessageFormatTron)
+	return s.SignHash(context.Background(), hash, KindMessage, []byte(msg))
+}
+
+// SignTypedData signs the TIP-712 hash of td, v is 27 or 28 like
+// wallet.Wallet
+func (s *Signer) SignTypedData(td *abi.TypedData) ([]byte, error) {
+	hash, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+	// the server hashes the json again, it must give the same hash
+	data, err := json.Marshal(td)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := abi.ParseTypedData(data)
+	if err != nil {
+		return nil, err
+	}
+	if sent, err := parsed.Hash(); err != nil || !bytes.Equal(sent, hash) {
+		return nil, fmt.Errorf("typed data does not keep its hash in json, give bytes as hex strings")
+	}
+	sig, err := s.SignHash(context.Background(), hash, KindTypedData, data)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
@@ -0,0 +1,136 @@
+package testutil
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+// activeDefaultOperations is ACTIVE_DEFAULT_OPERATIONS of java-tron, the
+// operations of the active permission of an account created without one
+var activeDefaultOperations = append([]byte{0x7f, 0xff, 0x1f, 0xc0, 0x03, 0x3e}, make([]byte, 26)...)
+
+// permission returns the permission id of acc the way java-tron resolves
+// it, with the default owner and active permissions of an account which has
+// none
+func permission(acc *core.Account, id int32) *core.Permission {
+	switch id {
+	case 0:
+		if acc.OwnerPermission != nil {
+			return acc.OwnerPermission
+		}
+		return &core.Permission{
+			Type:           core.Permission_Owner,
+			PermissionName: "owner",
+			Threshold:      1,
+			Keys:           []*core.Key{{Address: acc.Address, Weight: 1}},
+		}
+	case 1:
+		return acc.WitnessPermission
+	}
+	for _, p := range acc.ActivePermission {
+		if p.Id == id {
+			return p
+		}
+	}
+	if id == 2 && len(acc.ActivePermission) == 0 {
+		return &core.Permission{
+			Type:           core.Permission_Active,
+			Id:             2,
+			PermissionName: "active",
+			Threshold:      1,
+			Operations:     activeDefaultOperations,
+			Keys:           []*core.Key{{Address: acc.Address, Weight: 1}},
+		}
+	}
+	return nil
+}
+
+// ownerAddress returns the owner_address of the contract of t
+func ownerAddress(t *core.Transaction) ([]byte, error) {
+	contracts := t.GetRawData().GetContract()
+	if len(contracts) == 0 {
+		return nil, fmt.Errorf("no contract")
+	}
+	msg, err := contracts[0].GetParameter().UnmarshalNew()
+	if err != nil {
+		return nil, err
+	}
+	owner, ok := msg.(interface{ GetOwnerAddress() []byte })
+	if !ok {
+		return nil, fmt.Errorf("%s has no owner address", contracts[0].Type)
+	}
+	return owner.GetOwnerAddress(), nil
+}
+
+// signWeight checks the signatures of t against the permission of its
+// owner account like TransactionCapsule.checkPermission and checkWeight of
+// java-tron
+func (s *Server) signWeight(t *core.Transaction) *api.TransactionSignWeight {
+	ret := &api.TransactionSignWeight{Result: &api.TransactionSignWeight_Result{}}
+	fail := func(code api.TransactionSignWeight_ResultResponseCode, format string, args ...any) *api.TransactionSignWeight {
+		ret.Result.Code = code
+		ret.Result.Message = fmt.Sprintf(format, args...)
+		return ret
+	}
+	owner, err := ownerAddress(t)
+	if err != nil {
+		return fail(api.TransactionSignWeight_Result_OTHER_ERROR, "%v", err)
+	}
+	acc := s.getAccount(owner, false)
+	if acc == nil {
+		return fail(api.TransactionSignWeight_Result_PERMISSION_ERROR, "account not exists")
+	}
+	contract := t.RawData.Contract[0]
+	perm := permission(acc, contract.PermissionId)
+	if perm == nil {
+		return fail(api.TransactionSignWeight_Result_PERMISSION_ERROR, "permission isn't exit")
+	}
+	ret.Permission = perm
+	if contract.PermissionId != 0 {
+		if perm.Type != core.Permission_Active {
+			return fail(api.TransactionSignWeight_Result_PERMISSION_ERROR, "Permission type is error")
+		}
+		typ := int(contract.Type)
+		if typ/8 >= len(perm.Operations) || perm.Operations[typ/8]&(1<<(typ%8)) == 0 {
+			return fail(api.TransactionSignWeight_Result_PERMISSION_ERROR, "Permission denied")
+		}
+	}
+	if len(t.Signature) > len(perm.Keys) {
+		return fail(api.TransactionSignWeight_Result_SIGNATURE_FORMAT_ERROR,
+			"Signature count is %d more than key counts of permission : %d", len(t.Signature), len(perm.Keys))
+	}
+
+	hash := txid(t)
+	for _, sig := range t.Signature {
+		signer, err := wallet.RecoverAddress(hash, sig)
+		if err != nil {
+			return fail(api.TransactionSignWeight_Result_SIGNATURE_FORMAT_ERROR, "%v", err)
+		}
+		var weight int64
+		for _, key := range perm.Keys {
+			if bytes.Equal(key.Address, signer) {
+				weight = key.Weight
+			}
+		}
+		if weight == 0 {
+			return fail(api.TransactionSignWeight_Result_PERMISSION_ERROR,
+				"%x is signed by %s but it is not contained of permission: %s", sig, signer, perm.PermissionName)
+		}
+		for _, approved := range ret.ApprovedList {
+			if bytes.Equal(approved, signer) {
+				return fail(api.TransactionSignWeight_Result_PERMISSION_ERROR, "%s has signed twice!", signer)
+			}
+		}
+		ret.ApprovedList = append(ret.ApprovedList, signer)
+		ret.CurrentWeight += weight
+	}
+	if ret.CurrentWeight < perm.Threshold {
+		return fail(api.TransactionSignWeight_Result_NOT_ENOUGH_PERMISSION,
+			"sign weight %d is less than threshold %d", ret.CurrentWeight, perm.Threshold)
+	}
+	return ret
+}
@@ -0,0 +1,147 @@
+package testutil_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/client"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"github.com/fullstackwang/tron-grpc/wallet"
+	"github.com/golang/protobuf/proto"
+)
+
+// signed returns t with permission id and the signatures of signers
+func signed(t *testing.T, tt *core.Transaction, id int32, signers ...*wallet.Wallet) *core.Transaction {
+	t.Helper()
+	tt = proto.Clone(tt).(*core.Transaction)
+	tt.RawData.Contract[0].PermissionId = id
+	for _, signer := range signers {
+		sig, err := signer.SignTransaction(tt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.Signature = append(tt.Signature, sig)
+	}
+	return tt
+}
+
+func signWeight(t *testing.T, c *client.Client, tt *core.Transaction) *api.TransactionSignWeight {
+	t.Helper()
+	w, err := c.GetTransactionSignWeight(context.Background(), tt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestServerSignWeight(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	owner, _ := wallet.Generate()
+	a, _ := wallet.Generate()
+	b, _ := wallet.Generate()
+	other, _ := wallet.Generate()
+	transfer := make([]byte, 32)
+	transfer[core.Transaction_Contract_TransferContract/8] = 1 << (core.Transaction_Contract_TransferContract % 8)
+	s.SetAccount(&core.Account{
+		Address: owner.Address(),
+		Balance: 1000,
+		ActivePermission: []*core.Permission{{
+			Type:       core.Permission_Active,
+			Id:         2,
+			Threshold:  3,
+			Operations: transfer,
+			Keys:       []*core.Key{{Address: a.Address(), Weight: 1}, {Address: b.Address(), Weight: 2}},
+		}, {
+			Type:       core.Permission_Active,
+			Id:         3,
+			Threshold:  1,
+			Operations: transfer,
+			Keys:       []*core.Key{{Address: a.Address(), Weight: 1}},
+		}, {
+			Type:      core.Permission_Active,
+			Id:        4,
+			Threshold: 1,
+			Keys:      []*core.Key{{Address: a.Address(), Weight: 1}},
+		}},
+	})
+	ext, err := c.CreateTransaction2(context.Background(), &core.TransferContract{
+		OwnerAddress: owner.Address(),
+		ToAddress:    other.Address(),
+		Amount:       1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		tx      *core.Transaction
+		code    api.TransactionSignWeight_ResultResponseCode
+		weight  int64
+		message string
+	}{
+		{"enough", signed(t, ext.Transaction, 2, a, b), api.TransactionSignWeight_Result_ENOUGH_PERMISSION, 3, ""},
+		{"below threshold", signed(t, ext.Transaction, 2, b), api.TransactionSignWeight_Result_NOT_ENOUGH_PERMISSION, 2, "threshold"},
+		{"signed twice", signed(t, ext.Transaction, 2, a, a), api.TransactionSignWeight_Result_PERMISSION_ERROR, 1, "signed twice"},
+		{"not a key", signed(t, ext.Transaction, 2, other), api.TransactionSignWeight_Result_PERMISSION_ERROR, 0, "not contained"},
+		{"too many signatures", signed(t, ext.Transaction, 3, a, b), api.TransactionSignWeight_Result_SIGNATURE_FORMAT_ERROR, 0, "more than key counts"},
+		{"operation denied", signed(t, ext.Transaction, 4, a), api.TransactionSignWeight_Result_PERMISSION_ERROR, 0, "Permission denied"},
+		{"no permission", signed(t, ext.Transaction, 5, a), api.TransactionSignWeight_Result_PERMISSION_ERROR, 0, "isn't exit"},
+		{"default owner", signed(t, ext.Transaction, 0, owner), api.TransactionSignWeight_Result_ENOUGH_PERMISSION, 1, ""},
+	} {
+		w := signWeight(t, c, tc.tx)
+		if w.Result.Code != tc.code || w.CurrentWeight != tc.weight || !strings.Contains(w.Result.Message, tc.message) {
+			t.Errorf("%s: %v weight %d %q, want %v weight %d", tc.name, w.Result.Code, w.CurrentWeight, w.Result.Message, tc.code, tc.weight)
+		}
+	}
+}
+
+// the default active permission of java-tron allows every contract but
+// AccountPermissionUpdateContract
+func TestServerDefaultActivePermission(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	owner, _ := wallet.Generate()
+	to, _ := wallet.Generate()
+	s.SetBalance(owner.Address(), 1000)
+	ext, err := c.CreateTransaction2(context.Background(), &core.TransferContract{
+		OwnerAddress: owner.Address(),
+		ToAddress:    to.Address(),
+		Amount:       1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := signWeight(t, c, signed(t, ext.Transaction, 2, owner)); w.Result.Code != api.TransactionSignWeight_Result_ENOUGH_PERMISSION {
+		t.Fatalf("transfer: %v %s", w.Result.Code, w.Result.Message)
+	}
+
+	ext, err = c.AccountPermissionUpdate(context.Background(), &core.AccountPermissionUpdateContract{
+		OwnerAddress: owner.Address(),
+		Owner:        &core.Permission{Type: core.Permission_Owner, Threshold: 1, Keys: []*core.Key{{Address: owner.Address(), Weight: 1}}},
+		Actives:      []*core.Permission{{Type: core.Permission_Active, Id: 2, Threshold: 1, Keys: []*core.Key{{Address: owner.Address(), Weight: 1}}}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := signWeight(t, c, signed(t, ext.Transaction, 2, owner))
+	if w.Result.Code != api.TransactionSignWeight_Result_PERMISSION_ERROR {
+		t.Fatalf("permission update with the default active: %v %s", w.Result.Code, w.Result.Message)
+	}
+}
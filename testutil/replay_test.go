@@ -0,0 +1,91 @@
+package testutil_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/client"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"github.com/fullstackwang/tron-grpc/trx"
+	"github.com/fullstackwang/tron-grpc/tx"
+	"github.com/fullstackwang/tron-grpc/wallet"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// transfer sends 100 sun from the signer of c and returns the balance of
+// the recipient once confirmed
+func transfer(t *testing.T, c *client.Client, to string) int64 {
+	t.Helper()
+	tr := trx.New(c)
+	tt, err := tr.Transfer(context.Background(), to, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tt.WaitConfirmationContext(context.Background(), &tx.WaitOptions{PollInterval: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	balance, err := tr.GetBalance(context.Background(), to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return balance
+}
+
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "transfer.jsonl")
+	owner, err := wallet.FromPrivateKey("0000000000000000000000000000000000000000000000000000000000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, _ := wallet.Generate()
+
+	s := testutil.NewServer()
+	s.AutoMine = true
+	s.SetBalance(owner.Address(), 1000)
+	rec, err := testutil.NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Use(rec.Interceptor())
+	c.Signer = owner
+	if got := transfer(t, c, to.Address().String()); got != 100 {
+		t.Fatalf("recorded balance %d, want 100", got)
+	}
+	c.Stop()
+	s.Stop()
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := testutil.NewReplayer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := r.Client()
+	rc.Signer = owner
+	if got := transfer(t, rc, to.Address().String()); got != 100 {
+		t.Fatalf("replayed balance %d, want 100", got)
+	}
+
+	// a request which was not recorded has no answer
+	_, err = r.WalletClient().GetNowBlock2(context.Background(), &api.EmptyMessage{})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("unrecorded call: %v", err)
+	}
+	acc, err := r.WalletClient().GetAccount(context.Background(), &core.Account{Address: to.Address()})
+	if err != nil {
+		t.Fatalf("recorded call: %v", err)
+	}
+	if acc.Balance != 100 {
+		t.Fatalf("replayed account balance %d, want 100", acc.Balance)
+	}
+}
@@ -0,0 +1,570 @@
+// Package testutil provides an in-memory Wallet node to test the client,
+// trx, tx and contract packages without a network
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/client"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	bufSize         = 1024 * 1024
+	blockInterval   = 3 * time.Second
+	expirationDelay = 60 * time.Second
+)
+
+// HandlerFunc replaces the built-in handling of an rpc method, req and the
+// returned message are the typed protobuf messages of the method
+type HandlerFunc func(ctx context.Context, req any) (any, error)
+
+// ContractHandler executes a call to a fake contract. The result is the
+// constant result of TriggerConstantContract and the contract result of the
+// transaction info. Returning a *RevertError reverts the call
+type ContractHandler func(call *core.TriggerSmartContract) (result []byte, logs []*core.TransactionInfo_Log, err error)
+
+// RevertError reverts a contract call, Data goes to the contract result
+type RevertError struct {
+	Data []byte
+}
+
+func (e *RevertError) Error() string {
+	return "REVERT opcode executed"
+}
+
+// Server is an in-memory implementation of api.WalletServer served over bufconn.
+// It keeps an account ledger and produces blocks on demand, or on every
+// broadcast when AutoMine is set
+type Server struct {
+	api.UnimplementedWalletServer
+
+	// AutoMine produces a block after every successful broadcast
+	AutoMine bool
+
+	mu        sync.Mutex
+	lis       *bufconn.Listener
+	server    *grpc.Server
+	handlers  map[string]HandlerFunc
+	contracts map[string]ContractHandler
+	accounts  map[string]*core.Account
+	blocks    []*api.BlockExtention
+	pending   []*core.Transaction
+	txs       map[string]*core.Transaction
+	infos     map[string]*core.TransactionInfo
+	// solidityLag is the number of blocks behind the head not yet
+	// solidified
+	solidityLag int64
+}
+
+// NewServer creates and starts a Server with a genesis block
+func NewServer() *Server {
+	s := &Server{
+		lis:       bufconn.Listen(bufSize),
+		handlers:  make(map[string]HandlerFunc),
+		contracts: make(map[string]ContractHandler),
+		accounts:  make(map[string]*core.Account),
+		txs:       make(map[string]*core.Transaction),
+		infos:     make(map[string]*core.TransactionInfo),
+	}
+	s.appendBlock(nil)
+	s.server = grpc.NewServer(grpc.UnaryInterceptor(s.intercept))
+	api.RegisterWalletServer(s.server, s)
+	api.RegisterWalletSolidityServer(s.server, &solidityServer{s: s})
+	go func() {
+		_ = s.server.Serve(s.lis)
+	}()
+	return s
+}
+
+// Stop stops the server
+func (s *Server) Stop() {
+	s.server.Stop()
+}
+
+// DialOptions returns the options to dial the server with grpc.Dial("bufnet", ...)
+func (s *Server) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return s.lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+}
+
+// PoolDialOptions returns the options to dial several servers, each at its
+// key, e.g. with client.NewWithEndpoints
+func PoolDialOptions(servers map[string]*Server) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			s, ok := servers[addr]
+			if !ok {
+				return nil, fmt.Errorf("no server at %s", addr)
+			}
+			return s.lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+}
+
+// Client returns a started client.Client connected to the server
+func (s *Server) Client() (*client.Client, error) {
+	c := client.New("bufnet", "")
+	return c, c.Start(s.DialOptions()...)
+}
+
+// Handle replaces the handling of an rpc method, method is the short name
+// like "GetAccount"
+func (s *Server) Handle(method string, handler HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = handler
+}
+
+func (s *Server) intercept(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	method := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+	s.mu.Lock()
+	h := s.handlers[method]
+	s.mu.Unlock()
+	if h != nil {
+		return h(ctx, req)
+	}
+	return handler(ctx, req)
+}
+
+// SetContract deploys a fake contract at addr
+func (s *Server) SetContract(addr address.Address, handler ContractHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contracts[string(addr)] = handler
+}
+
+// SetBalance creates the account if needed and sets its balance in sun
+func (s *Server) SetBalance(addr address.Address, balance int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.getAccount(addr, true).Balance = balance
+}
+
+// SetAccount stores a copy of acc, to set up permissions for example
+func (s *Server) SetAccount(acc *core.Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.accounts[string(acc.Address)] = proto.Clone(acc).(*core.Account)
+}
+
+// Balance returns the balance of an account in sun
+func (s *Server) Balance(addr address.Address) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.getAccount(addr, false).GetBalance()
+}
+
+func (s *Server) getAccount(addr []byte, create bool) *core.Account {
+	acc := s.accounts[string(addr)]
+	if acc == nil && create {
+		acc = &core.Account{
+			Address:    addr,
+			CreateTime: s.head().GetBlockHeader().GetRawData().GetTimestamp(),
+		}
+		s.accounts[string(addr)] = acc
+	}
+	return acc
+}
+
+// SetSolidityLag sets the number of blocks behind the head not yet
+// solidified, the WalletSolidity service only sees the others. 0 by default
+func (s *Server) SetSolidityLag(blocks int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.solidityLag = blocks
+}
+
+// Pending returns a copy of the broadcast transactions waiting for the
+// next block
+func (s *Server) Pending() []*core.Transaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pending := make([]*core.Transaction, 0, len(s.pending))
+	for _, t := range s.pending {
+		pending = append(pending, clone(t))
+	}
+	return pending
+}
+
+// ProduceBlock executes the pending transactions in a new block and returns
+// a copy of it
+func (s *Server) ProduceBlock() *api.BlockExtention {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return clone(s.produceBlock())
+}
+
+// clone copies a message of the ledger, which must not be shared with the
+// callers once the lock is released
+func clone[M proto.Message](m M) M {
+	return proto.Clone(m).(M)
+}
+
+func (s *Server) head() *api.BlockExtention {
+	if len(s.blocks) == 0 {
+		return nil
+	}
+	return s.blocks[len(s.blocks)-1]
+}
+
+func (s *Server) produceBlock() *api.BlockExtention {
+	txs := s.pending
+	s.pending = nil
+	block := s.appendBlock(txs)
+	for _, t := range txs {
+		s.execute(block, t)
+	}
+	return block
+}
+
+func (s *Server) appendBlock(txs []*core.Transaction) *api.BlockExtention {
+	timestamp := time.Now().UnixMilli()
+	var number int64
+	var parentHash []byte
+	if head := s.head(); head != nil {
+		number = head.BlockHeader.RawData.Number + 1
+		parentHash = head.Blockid
+		if t := head.BlockHeader.RawData.Timestamp + blockInterval.Milliseconds(); t > timestamp {
+			timestamp = t
+		}
+	}
+	raw := &core.BlockHeaderRaw{
+		Timestamp:  timestamp,
+		ParentHash: parentHash,
+		Number:     number,
+	}
+	block := &api.BlockExtention{
+		BlockHeader: &core.BlockHeader{RawData: raw},
+		Blockid:     blockID(raw),
+	}
+	for _, t := range txs {
+		block.Transactions = append(block.Transactions, &api.TransactionExtention{
+			Transaction: t,
+			Txid:        txid(t),
+		})
+	}
+	s.blocks = append(s.blocks, block)
+	return block
+}
+
+// blockID is the number followed by the tail of the header hash, as java-tron does
+func blockID(raw *core.BlockHeaderRaw) []byte {
+	data, _ := proto.Marshal(raw)
+	hash := sha256.Sum256(data)
+	binary.BigEndian.PutUint64(hash[:8], uint64(raw.Number))
+	return hash[:]
+}
+
+func txid(t *core.Transaction) []byte {
+	data, _ := proto.Marshal(t.GetRawData())
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
+
+func (s *Server) newTransaction(typ core.Transaction_Contract_ContractType, msg proto.Message) (*api.TransactionExtention, error) {
+	param, err := anypb.New(proto.MessageV2(msg))
+	if err != nil {
+		return nil, err
+	}
+	head := s.head()
+	now := time.Now().UnixMilli()
+	t := &core.Transaction{
+		RawData: &core.TransactionRaw{
+			RefBlockBytes: head.Blockid[6:8],
+			RefBlockHash:  head.Blockid[8:16],
+			Expiration:    head.BlockHeader.RawData.Timestamp + expirationDelay.Milliseconds(),
+			Timestamp:     now,
+			Contract: []*core.Transaction_Contract{{
+				Type:      typ,
+				Parameter: param,
+			}},
+		},
+	}
+	return &api.TransactionExtention{
+		Transaction: t,
+		Txid:        txid(t),
+		Result:      &api.Return{Result: true, Code: api.Return_SUCCESS},
+	}, nil
+}
+
+func failed(code api.ReturnResponseCode, format string, args ...any) *api.TransactionExtention {
+	return &api.TransactionExtention{
+		Result: &api.Return{Code: code, Message: []byte(fmt.Sprintf(format, args...))},
+	}
+}
+
+// execute applies a transaction of block to the ledger and records its info
+func (s *Server) execute(block *api.BlockExtention, t *core.Transaction) {
+	id := txid(t)
+	info := &core.TransactionInfo{
+		Id:             id,
+		BlockNumber:    block.BlockHeader.RawData.Number,
+		BlockTimeStamp: block.BlockHeader.RawData.Timestamp,
+		Receipt:        &core.ResourceReceipt{},
+	}
+	ret := &core.Transaction_Result{}
+	t.Ret = []*core.Transaction_Result{ret}
+
+	if err := s.apply(t, info); err != nil {
+		info.Result = core.TransactionInfo_FAILED
+		info.ResMessage = []byte(err.Error())
+		ret.Ret = core.Transaction_Result_FAILED
+		if info.Receipt.Result == core.Transaction_Result_DEFAULT {
+			info.Receipt.Result = core.Transaction_Result_UNKNOWN
+		}
+	}
+	ret.ContractRet = info.Receipt.Result
+	s.txs[string(id)] = t
+	s.infos[string(id)] = info
+}
+
+func (s *Server) apply(t *core.Transaction, info *core.TransactionInfo) error {
+	c := t.GetRawData().GetContract()[0]
+	msg, err := c.GetParameter().UnmarshalNew()
+	if err != nil {
+		return err
+	}
+	switch v := msg.(type) {
+	case *core.TransferContract:
+		from := s.getAccount(v.OwnerAddress, false)
+		if from.GetBalance() < v.Amount {
+			return fmt.Errorf("balance is not sufficient")
+		}
+		from.Balance -= v.Amount
+		s.getAccount(v.ToAddress, true).Balance += v.Amount
+	case *core.AccountCreateContract:
+		if s.getAccount(v.AccountAddress, false) != nil {
+			return fmt.Errorf("account has existed")
+		}
+		s.getAccount(v.AccountAddress, true)
+	case *core.AccountPermissionUpdateContract:
+		acc := s.getAccount(v.OwnerAddress, false)
+		acc.OwnerPermission = v.Owner
+		acc.WitnessPermission = v.Witness
+		acc.ActivePermission = v.Actives
+	case *core.TriggerSmartContract:
+		info.ContractAddress = v.ContractAddress
+		result, logs, err := s.call(v)
+		info.Log = logs
+		if err != nil {
+			info.Receipt.Result = core.Transaction_Result_REVERT
+			if revert, ok := err.(*RevertError); ok {
+				info.ContractResult = [][]byte{revert.Data}
+			}
+			return err
+		}
+		info.ContractResult = [][]byte{result}
+		info.Receipt.Result = core.Transaction_Result_SUCCESS
+	default:
+		return fmt.Errorf("contract type %s not supported", c.Type)
+	}
+	return nil
+}
+
+func (s *Server) call(call *core.TriggerSmartContract) ([]byte, []*core.TransactionInfo_Log, error) {
+	handler := s.contracts[string(call.ContractAddress)]
+	if handler == nil {
+		return nil, nil, fmt.Errorf("no contract at %s", address.Address(call.ContractAddress))
+	}
+	return handler(call)
+}
+
+func (s *Server) GetAccount(_ context.Context, in *core.Account) (*core.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	acc := s.getAccount(in.Address, false)
+	if acc == nil {
+		return &core.Account{}, nil
+	}
+	return proto.Clone(acc).(*core.Account), nil
+}
+
+// chainParameters are the parameters of mainnet the fake node reports
+var chainParameters = []*core.ChainParameters_ChainParameter{
+	{Key: "getTotalSignNum", Value: 5},
+	{Key: "getUpdateAccountPermissionFee", Value: 100_000_000},
+	{Key: "getAllowMultiSign", Value: 1},
+	{Key: "getAllowTvmConstantinople", Value: 1},
+	{Key: "getChangeDelegation", Value: 1},
+	{Key: "getAllowMarketTransaction", Value: 1},
+}
+
+// GetChainParameters reports mainnet parameters, Handle replaces them
+func (s *Server) GetChainParameters(context.Context, *api.EmptyMessage) (*core.ChainParameters, error) {
+	params := &core.ChainParameters{}
+	for _, p := range chainParameters {
+		params.ChainParameter = append(params.ChainParameter, clone(p))
+	}
+	return params, nil
+}
+
+func (s *Server) GetNowBlock2(context.Context, *api.EmptyMessage) (*api.BlockExtention, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return clone(s.head()), nil
+}
+
+func (s *Server) GetBlockByNum2(_ context.Context, in *api.NumberMessage) (*api.BlockExtention, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if in.Num < 0 || in.Num >= int64(len(s.blocks)) {
+		return &api.BlockExtention{}, nil
+	}
+	return clone(s.blocks[in.Num]), nil
+}
+
+func (s *Server) CreateTransaction2(_ context.Context, in *core.TransferContract) (*api.TransactionExtention, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bytes.Equal(in.OwnerAddress, in.ToAddress) {
+		return failed(api.Return_CONTRACT_VALIDATE_ERROR, "Cannot transfer TRX to yourself."), nil
+	}
+	if in.Amount <= 0 {
+		return failed(api.Return_CONTRACT_VALIDATE_ERROR, "Amount must be greater than 0."), nil
+	}
+	owner := s.getAccount(in.OwnerAddress, false)
+	if owner == nil {
+		return failed(api.Return_CONTRACT_VALIDATE_ERROR, "Validate TransferContract error, no OwnerAccount."), nil
+	}
+	if owner.Balance < in.Amount {
+		return failed(api.Return_CONTRACT_VALIDATE_ERROR, "Validate TransferContract error, balance is not sufficient."), nil
+	}
+	return s.newTransaction(core.Transaction_Contract_TransferContract, in)
+}
+
+func (s *Server) CreateAccount2(_ context.Context, in *core.AccountCreateContract) (*api.TransactionExtention, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.getAccount(in.OwnerAddress, false) == nil {
+		return failed(api.Return_CONTRACT_VALIDATE_ERROR, "Account[%s] not exists", address.Address(in.OwnerAddress)), nil
+	}
+	if s.getAccount(in.AccountAddress, false) != nil {
+		return failed(api.Return_CONTRACT_VALIDATE_ERROR, "Account has existed"), nil
+	}
+	return s.newTransaction(core.Transaction_Contract_AccountCreateContract, in)
+}
+
+func (s *Server) AccountPermissionUpdate(_ context.Context, in *core.AccountPermissionUpdateContract) (*api.TransactionExtention, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.getAccount(in.OwnerAddress, false) == nil {
+		return failed(api.Return_CONTRACT_VALIDATE_ERROR, "ownerAddress account does not exist"), nil
+	}
+	if in.Owner == nil {
+		return failed(api.Return_CONTRACT_VALIDATE_ERROR, "owner permission is missed"), nil
+	}
+	if len(in.Actives) == 0 {
+		return failed(api.Return_CONTRACT_VALIDATE_ERROR, "active permission is missed"), nil
+	}
+	return s.newTransaction(core.Transaction_Contract_AccountPermissionUpdateContract, in)
+}
+
+func (s *Server) TriggerContract(_ context.Context, in *core.TriggerSmartContract) (*api.TransactionExtention, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.contracts[string(in.ContractAddress)] == nil {
+		return failed(api.Return_CONTRACT_VALIDATE_ERROR, "No contract or not a smart contract"), nil
+	}
+	return s.newTransaction(core.Transaction_Contract_TriggerSmartContract, in)
+}
+
+func (s *Server) TriggerConstantContract(_ context.Context, in *core.TriggerSmartContract) (*api.TransactionExtention, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.contracts[string(in.ContractAddress)] == nil {
+		return failed(api.Return_CONTRACT_VALIDATE_ERROR, "No contract or not a smart contract"), nil
+	}
+	ret, err := s.newTransaction(core.Transaction_Contract_TriggerSmartContract, in)
+	if err != nil {
+		return nil, err
+	}
+	result, logs, err := s.call(in)
+	ret.Logs = logs
+	if err != nil {
+		ret.Result = &api.Return{Code: api.Return_CONTRACT_EXE_ERROR, Message: []byte(err.Error())}
+		if revert, ok := err.(*RevertError); ok {
+			ret.ConstantResult = [][]byte{revert.Data}
+		}
+		return ret, nil
+	}
+	ret.ConstantResult = [][]byte{result}
+	return ret, nil
+}
+
+func (s *Server) GetTransactionSignWeight(_ context.Context, in *core.Transaction) (*api.TransactionSignWeight, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.signWeight(in), nil
+}
+
+func (s *Server) BroadcastTransaction(_ context.Context, in *core.Transaction) (*api.Return, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(in.Signature) == 0 {
+		return &api.Return{Code: api.Return_SIGERROR, Message: []byte("miss sig or contract")}, nil
+	}
+	if len(in.GetRawData().GetContract()) != 1 {
+		return &api.Return{Code: api.Return_CONTRACT_VALIDATE_ERROR, Message: []byte("contract size should be exactly 1")}, nil
+	}
+	if w := s.signWeight(in); w.Result.Code != api.TransactionSignWeight_Result_ENOUGH_PERMISSION {
+		return &api.Return{Code: api.Return_SIGERROR, Message: []byte(w.Result.Message)}, nil
+	}
+	id := txid(in)
+	if _, ok := s.txs[string(id)]; ok {
+		return &api.Return{Code: api.Return_DUP_TRANSACTION_ERROR, Message: []byte("dup transaction")}, nil
+	}
+	for _, t := range s.pending {
+		if bytes.Equal(txid(t), id) {
+			return &api.Return{Code: api.Return_DUP_TRANSACTION_ERROR, Message: []byte("dup transaction")}, nil
+		}
+	}
+	if in.RawData.Expiration <= s.head().BlockHeader.RawData.Timestamp {
+		return &api.Return{Code: api.Return_TRANSACTION_EXPIRATION_ERROR, Message: []byte("transaction expired")}, nil
+	}
+	s.pending = append(s.pending, proto.Clone(in).(*core.Transaction))
+	if s.AutoMine {
+		s.produceBlock()
+	}
+	return &api.Return{Result: true, Code: api.Return_SUCCESS}, nil
+}
+
+func (s *Server) GetTransactionById(_ context.Context, in *api.BytesMessage) (*core.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t := s.txs[string(in.Value)]
+	if t == nil {
+		return &core.Transaction{}, nil
+	}
+	return clone(t), nil
+}
+
+func (s *Server) GetTransactionInfoById(_ context.Context, in *api.BytesMessage) (*core.TransactionInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := s.infos[string(in.Value)]
+	if info == nil {
+		return &core.TransactionInfo{}, nil
+	}
+	return clone(info), nil
+}
@@ -0,0 +1,153 @@
+package testutil_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+// the messages handed out must not alias the ledger
+func TestServerReturnsCopies(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	block := s.ProduceBlock()
+	number := block.BlockHeader.RawData.Number
+	block.BlockHeader.RawData.Number = 1000
+	head, err := c.GetNowBlock2(context.Background(), &api.EmptyMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := head.BlockHeader.RawData.Number; n != number {
+		t.Fatalf("head %d, want %d", n, number)
+	}
+
+	owner, _ := wallet.Generate()
+	to, _ := wallet.Generate()
+	s.SetBalance(owner.Address(), 1000)
+	ext, err := c.CreateTransaction2(context.Background(), &core.TransferContract{
+		OwnerAddress: owner.Address(),
+		ToAddress:    to.Address(),
+		Amount:       100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := owner.SignTransaction(ext.Transaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext.Transaction.Signature = [][]byte{sig}
+	if _, err := c.BroadcastTransaction(context.Background(), ext.Transaction); err != nil {
+		t.Fatal(err)
+	}
+	s.Pending()[0].Signature = nil
+	if sigs := s.Pending()[0].Signature; len(sigs) != 1 {
+		t.Fatalf("pending transaction has %d signatures, want 1", len(sigs))
+	}
+}
+
+func TestServerTransfer(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	owner, _ := wallet.Generate()
+	to, _ := wallet.Generate()
+	s.SetBalance(owner.Address(), 1000)
+	ext, err := c.CreateTransaction2(context.Background(), &core.TransferContract{
+		OwnerAddress: owner.Address(),
+		ToAddress:    to.Address(),
+		Amount:       2000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ext.Result.Code != api.Return_CONTRACT_VALIDATE_ERROR {
+		t.Fatalf("transfer over the balance: %s", ext.Result.Code)
+	}
+
+	ext, err = c.CreateTransaction2(context.Background(), &core.TransferContract{
+		OwnerAddress: owner.Address(),
+		ToAddress:    to.Address(),
+		Amount:       100,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err := c.BroadcastTransaction(context.Background(), ext.Transaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ret.Code != api.Return_SIGERROR {
+		t.Fatalf("unsigned broadcast: %s", ret.Code)
+	}
+	sig, err := owner.SignTransaction(ext.Transaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ext.Transaction.Signature = [][]byte{sig}
+	for _, want := range []api.ReturnResponseCode{api.Return_SUCCESS, api.Return_DUP_TRANSACTION_ERROR} {
+		ret, err := c.BroadcastTransaction(context.Background(), ext.Transaction)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ret.Code != want {
+			t.Fatalf("broadcast: %s, want %s", ret.Code, want)
+		}
+	}
+	if s.Balance(to.Address()) != 0 {
+		t.Fatal("transfer executed before its block")
+	}
+
+	block := s.ProduceBlock()
+	if len(block.Transactions) != 1 || len(s.Pending()) != 0 {
+		t.Fatalf("block has %d transactions, %d pending", len(block.Transactions), len(s.Pending()))
+	}
+	if s.Balance(owner.Address()) != 900 || s.Balance(to.Address()) != 100 {
+		t.Fatalf("balances %d and %d, want 900 and 100", s.Balance(owner.Address()), s.Balance(to.Address()))
+	}
+	info, err := c.GetTransactionInfoById(context.Background(), &api.BytesMessage{Value: ext.Txid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.BlockNumber != block.BlockHeader.RawData.Number || info.Result != core.TransactionInfo_SUCESS {
+		t.Fatalf("info in block %d with %s", info.BlockNumber, info.Result)
+	}
+}
+
+func TestServerSolidityLag(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	c.SetSolidityAddress("bufnet")
+
+	s.SetSolidityLag(2)
+	for i := 0; i < 3; i++ {
+		s.ProduceBlock()
+	}
+	solidified, err := c.WalletSolidity().GetNowBlock2(context.Background(), &api.EmptyMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := solidified.BlockHeader.RawData.Number; n != 1 {
+		t.Fatalf("solidified block %d, want 1", n)
+	}
+}
@@ -0,0 +1,52 @@
+package testutil
+
+import (
+	"context"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/core"
+)
+
+// solidityServer is the WalletSolidity service of Server, it sees the
+// blocks up to solidityLag blocks behind the head
+type solidityServer struct {
+	api.UnimplementedWalletSolidityServer
+	s *Server
+}
+
+// solidified returns the latest solidified block
+func (s *Server) solidified() *api.BlockExtention {
+	n := int64(len(s.blocks)) - 1 - s.solidityLag
+	if n < 0 {
+		n = 0
+	}
+	return s.blocks[n]
+}
+
+func (ss *solidityServer) GetNowBlock2(context.Context, *api.EmptyMessage) (*api.BlockExtention, error) {
+	ss.s.mu.Lock()
+	defer ss.s.mu.Unlock()
+	return clone(ss.s.solidified()), nil
+}
+
+func (ss *solidityServer) GetTransactionInfoById(_ context.Context, in *api.BytesMessage) (*core.TransactionInfo, error) {
+	s := ss.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := s.infos[string(in.Value)]
+	if info == nil || info.BlockNumber > s.solidified().BlockHeader.RawData.Number {
+		return &core.TransactionInfo{}, nil
+	}
+	return clone(info), nil
+}
+
+func (ss *solidityServer) GetTransactionById(_ context.Context, in *api.BytesMessage) (*core.Transaction, error) {
+	s := ss.s
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info := s.infos[string(in.Value)]
+	if info == nil || info.BlockNumber > s.solidified().BlockHeader.RawData.Number {
+		return &core.Transaction{}, nil
+	}
+	return clone(s.txs[string(in.Value)]), nil
+}
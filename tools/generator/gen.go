@@ -17,6 +17,22 @@ type method struct {
 	Out  string
 }
 
+type service struct {
+	Interface string
+	Receiver  string
+	Parent    string
+	Client    string
+}
+
+var services = []service{
+	{Interface: "WalletClient", Receiver: "c *Client", Parent: "c", Client: "c.client"},
+	{Interface: "WalletSolidityClient", Receiver: "s *SolidityClient", Parent: "s.c", Client: "s.c.solidity"},
+	{Interface: "WalletExtensionClient", Receiver: "s *ExtensionClient", Parent: "s.c", Client: "s.c.extension"},
+	{Interface: "DatabaseClient", Receiver: "s *DatabaseClient", Parent: "s.c", Client: "s.c.database"},
+	{Interface: "MonitorClient", Receiver: "s *MonitorClient", Parent: "s.c", Client: "s.c.monitor"},
+	{Interface: "NetworkClient", Receiver: "s *NetworkClient", Parent: "s.c", Client: "s.c.network"},
+}
+
 func getInterface(node *ast.File, name string) *ast.InterfaceType {
 	for _, dec := range node.Decls {
 		if gen, ok := dec.(*ast.GenDecl); ok {
@@ -89,14 +105,14 @@ func processApiType(t string) string {
 	return strings.Replace(t, "*", "*api.", 1)
 }
 
-func genMethod(f *os.File, m method) {
+func genMethod(f *os.File, s service, m method) {
 	f.WriteString(fmt.Sprintf(`
-func (c *Client) %s(ctx context.Context, in %s, opts ...grpc.CallOption) (%s, error) {
-	ctx, cancel := c.makeContext(ctx)
+func (%s) %s(ctx context.Context, in %s, opts ...grpc.CallOption) (%s, error) {
+	ctx, cancel := %s.makeContext(ctx)
 	defer cancel()
-	return c.client.%s(ctx, in, opts...)
+	return %s.%s(ctx, in, opts...)
 }
-`, m.Name, processApiType(m.In), processApiType(m.Out), m.Name))
+`, s.Receiver, m.Name, processApiType(m.In), processApiType(m.Out), s.Parent, s.Client, m.Name))
 }
 
 func main() {
@@ -106,9 +122,6 @@ func main() {
 		log.Fatal(err)
 	}
 
-	iface := getInterface(node, "WalletClient")
-	methods := getMethods(iface)
-
 	f, err := os.Create("generated.go")
 	if err != nil {
 		log.Fatalln(err)
@@ -124,7 +137,13 @@ import (
 )
 `)
 
-	for _, m := range methods {
-		genMethod(f, m)
+	for _, s := range services {
+		iface := getInterface(node, s.Interface)
+		if iface == nil {
+			log.Fatalf("interface %s not found", s.Interface)
+		}
+		for _, m := range getMethods(iface) {
+			genMethod(f, s, m)
+		}
 	}
 }
@@ -0,0 +1,358 @@
+package trx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/tx"
+	"github.com/golang/protobuf/proto"
+)
+
+// limits of java-tron, MaxPermissionKeys is the TOTAL_SIGN_NUM chain
+// parameter of mainnet
+const (
+	MaxPermissionKeys     = 5
+	MaxActivePermissions  = 8
+	MaxPermissionNameSize = 32
+	operationsSize        = 32
+)
+
+var ErrInvalidPermission = fmt.Errorf("invalid permission")
+
+// DefaultAvailableContractTypes is the bitmask of the contract types
+// java-tron accepts in active permissions before any proposal
+var DefaultAvailableContractTypes = append([]byte{0x7f, 0xff, 0x1f, 0xc0, 0x03, 0x7e}, make([]byte, operationsSize-6)...)
+
+// proposalContractTypes are the contract types made available by the chain
+// parameters, when they are not 0
+var proposalContractTypes = map[string][]core.Transaction_Contract_ContractType{
+	"getAllowTvmConstantinople": {core.Transaction_Contract_ClearABIContract},
+	"getChangeDelegation":       {core.Transaction_Contract_UpdateBrokerageContract},
+	"getAllowMarketTransaction": {core.Transaction_Contract_MarketSellAssetContract, core.Transaction_Contract_MarketCancelOrderContract},
+}
+
+// AvailableContractTypes returns the bitmask of the contract types allowed
+// in active permissions by a chain with params
+func AvailableContractTypes(params *core.ChainParameters) []byte {
+	mask := append([]byte(nil), DefaultAvailableContractTypes...)
+	for _, param := range params.GetChainParameter() {
+		if param.Value == 0 {
+			continue
+		}
+		for _, t := range proposalContractTypes[param.Key] {
+			mask[t/8] |= 1 << (t % 8)
+		}
+	}
+	return mask
+}
+
+// PermissionKey is an address allowed to sign for a permission
+type PermissionKey struct {
+	Address address.Address
+	Weight  int64
+}
+
+// Permission is an owner, witness or active permission of an account. The
+// operations are only used by active permissions
+type Permission struct {
+	Name       string
+	Threshold  int64
+	Keys       []PermissionKey
+	Operations []core.Transaction_Contract_ContractType
+}
+
+// NewPermission creates a permission with a name and a threshold
+func NewPermission(name string, threshold int64) *Permission {
+	return &Permission{Name: name, Threshold: threshold}
+}
+
+// AddKey adds a key with its weight
+func (p *Permission) AddKey(addr address.Address, weight int64) *Permission {
+	p.Keys = append(p.Keys, PermissionKey{Address: addr, Weight: weight})
+	return p
+}
+
+// Allow adds contract types to the operations of an active permission
+func (p *Permission) Allow(types ...core.Transaction_Contract_ContractType) *Permission {
+	p.Operations = append(p.Operations, types...)
+	return p
+}
+
+// Permissions is the full set of permissions of an account, as set by an
+// AccountPermissionUpdateContract. Witness is only for witness accounts
+type Permissions struct {
+	Owner   *Permission
+	Witness *Permission
+	Actives []*Permission
+}
+
+// OperationsMask returns the 32 bytes operations bitmask allowing types,
+// contract type n is bit n%8 of byte n/8
+func OperationsMask(types ...core.Transaction_Contract_ContractType) []byte {
+	mask := make([]byte, operationsSize)
+	for _, t := range types {
+		if t >= 0 && int(t) < operationsSize*8 {
+			mask[t/8] |= 1 << (t % 8)
+		}
+	}
+	return mask
+}
+
+// ParseOperations returns the contract types allowed by an operations bitmask
+func ParseOperations(mask []byte) []core.Transaction_Contract_ContractType {
+	var types []core.Transaction_Contract_ContractType
+	for i, b := range mask {
+		for bit := 0; bit < 8; bit++ {
+			if b&(1<<bit) != 0 {
+				types = append(types, core.Transaction_Contract_ContractType(i*8+bit))
+			}
+		}
+	}
+	return types
+}
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", ErrInvalidPermission, fmt.Sprintf(format, args...))
+}
+
+// validate checks p like the AccountPermissionUpdateActuator of java-tron
+func (p *Permission) validate(typ core.Permission_PermissionType) error {
+	if p == nil {
+		return invalid("%s permission is missed", typ)
+	}
+	switch {
+	case len(p.Keys) > MaxPermissionKeys:
+		return invalid("number of keys in permission should not be greater than %d", MaxPermissionKeys)
+	case len(p.Keys) == 0:
+		return invalid("key's count should be greater than 0")
+	case typ == core.Permission_Witness && len(p.Keys) != 1:
+		return invalid("Witness permission's key count should be 1")
+	case p.Threshold <= 0:
+		return invalid("permission's threshold should be greater than 0")
+	case len(p.Name) > MaxPermissionNameSize:
+		return invalid("permission's name is too long")
+	}
+
+	var sum int64
+	for i, key := range p.Keys {
+		if len(key.Address) != 21 || key.Address[0] != 0x41 {
+			return invalid("key is not a validate address")
+		}
+		if key.Weight <= 0 {
+			return invalid("key's weight should be greater than 0")
+		}
+		if sum > math.MaxInt64-key.Weight {
+			return invalid("sum of key's weight overflows")
+		}
+		sum += key.Weight
+		for _, other := range p.Keys[:i] {
+			if bytes.Equal(other.Address, key.Address) {
+				return invalid("address should be distinct in permission %s", typ)
+			}
+		}
+	}
+	if sum < p.Threshold {
+		return invalid("sum of all key's weight should not be less than threshold in permission %s", typ)
+	}
+
+	if typ != core.Permission_Active {
+		if len(p.Operations) > 0 {
+			return invalid("%s permission needn't operations", typ)
+		}
+		return nil
+	}
+	if len(p.Operations) == 0 {
+		return invalid("active permission needs operations")
+	}
+	for _, op := range p.Operations {
+		if _, ok := core.Transaction_Contract_ContractType_name[int32(op)]; !ok {
+			return invalid("%d isn't a validate ContractType", op)
+		}
+	}
+	return nil
+}
+
+// CheckOperations checks the operations of the actives against the bitmask
+// of available contract types of the chain, see AvailableContractTypes
+func (ps *Permissions) CheckOperations(available []byte) error {
+	for _, active := range ps.Actives {
+		for _, op := range active.Operations {
+			if op < 0 || int(op) >= len(available)*8 || available[op/8]&(1<<(op%8)) == 0 {
+				return invalid("%d isn't a validate ContractType", op)
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks the permissions with the rules of java-tron, the node
+// also requires the witness permission if and only if the account is a
+// witness. The operations are checked against the chain by CheckOperations
+func (ps *Permissions) Validate() error {
+	if err := ps.Owner.validate(core.Permission_Owner); err != nil {
+		return err
+	}
+	if ps.Witness != nil {
+		if err := ps.Witness.validate(core.Permission_Witness); err != nil {
+			return err
+		}
+	}
+	if len(ps.Actives) == 0 {
+		return invalid("active permission is missed")
+	}
+	if len(ps.Actives) > MaxActivePermissions {
+		return invalid("active permission is too many")
+	}
+	for _, active := range ps.Actives {
+		if err := active.validate(core.Permission_Active); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Permission) proto(typ core.Permission_PermissionType, id int32) *core.Permission {
+	perm := &core.Permission{
+		Type:           typ,
+		Id:             id,
+		PermissionName: p.Name,
+		Threshold:      p.Threshold,
+	}
+	if typ == core.Permission_Active {
+		perm.Operations = OperationsMask(p.Operations...)
+	}
+	for _, key := range p.Keys {
+		perm.Keys = append(perm.Keys, &core.Key{Address: key.Address, Weight: key.Weight})
+	}
+	return perm
+}
+
+// Contract validates the permissions and builds the contract updating the
+// permissions of owner. The ids are those the node assigns, the actives
+// start at 2 in order
+func (ps *Permissions) Contract(owner address.Address) (*core.AccountPermissionUpdateContract, error) {
+	if err := ps.Validate(); err != nil {
+		return nil, err
+	}
+	contract := &core.AccountPermissionUpdateContract{
+		OwnerAddress: owner,
+		Owner:        ps.Owner.proto(core.Permission_Owner, tx.OwnerPermissionID),
+	}
+	if ps.Witness != nil {
+		contract.Witness = ps.Witness.proto(core.Permission_Witness, tx.WitnessPermissionID)
+	}
+	for i, active := range ps.Actives {
+		contract.Actives = append(contract.Actives, active.proto(core.Permission_Active, tx.ActivePermissionID+int32(i)))
+	}
+	return contract, nil
+}
+
+func fromProto(perm *core.Permission) *Permission {
+	p := &Permission{
+		Name:      perm.PermissionName,
+		Threshold: perm.Threshold,
+	}
+	for _, key := range perm.Keys {
+		p.Keys = append(p.Keys, PermissionKey{Address: key.Address, Weight: key.Weight})
+	}
+	if perm.Type == core.Permission_Active {
+		p.Operations = ParseOperations(perm.Operations)
+	}
+	return p
+}
+
+// PermissionsFromAccount decodes the permissions of acc, an account without
+// explicit owner permission is owned by its own key
+func PermissionsFromAccount(acc *core.Account) (*Permissions, error) {
+	owner, err := tx.GetPermission(acc, tx.OwnerPermissionID)
+	if err != nil {
+		return nil, err
+	}
+	ps := &Permissions{Owner: fromProto(owner)}
+	if acc.WitnessPermission != nil {
+		ps.Witness = fromProto(acc.WitnessPermission)
+	}
+	actives := make([]*core.Permission, len(acc.ActivePermission))
+	copy(actives, acc.ActivePermission)
+	sort.Slice(actives, func(i, j int) bool { return actives[i].Id < actives[j].Id })
+	for _, active := range actives {
+		ps.Actives = append(ps.Actives, fromProto(active))
+	}
+	return ps, nil
+}
+
+// GetPermissions returns the permissions of account
+func (c *Client) GetPermissions(ctx context.Context, account string) (*Permissions, error) {
+	acc, err := c.GetAccount(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	if len(acc.GetAddress()) == 0 {
+		return nil, fmt.Errorf("account %s not found", account)
+	}
+	return PermissionsFromAccount(acc)
+}
+
+// BuildPermissionUpdate returns the unsigned transaction replacing the
+// permissions of account. Sign it with tx.NewMultiSig and
+// tx.OwnerPermissionID when the owner permission needs several keys
+func (c *Client) BuildPermissionUpdate(ctx context.Context, account string, ps *Permissions) (*tx.Transaction, error) {
+	owner, err := address.FromBase58(account)
+	if err != nil {
+		return nil, err
+	}
+	return c.buildPermissionUpdate(ctx, owner, ps)
+}
+
+func (c *Client) buildPermissionUpdate(ctx context.Context, owner address.Address, ps *Permissions) (*tx.Transaction, error) {
+	params, err := c.client.GetChainParameters(ctx, &api.EmptyMessage{})
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.CheckOperations(AvailableContractTypes(params)); err != nil {
+		return nil, err
+	}
+	acc, err := c.client.GetAccount(ctx, &core.Account{Address: owner})
+	if err != nil {
+		return nil, err
+	}
+	if acc.IsWitness && ps.Witness == nil {
+		return nil, invalid("witness permission is missed")
+	}
+	if !acc.IsWitness && ps.Witness != nil {
+		return nil, invalid("account isn't witness can't set witness permission")
+	}
+	contract, err := ps.Contract(owner)
+	if err != nil {
+		return nil, err
+	}
+
+	tx_, err := c.client.AccountPermissionUpdate(ctx, contract)
+	if err != nil {
+		return nil, err
+	}
+	if proto.Size(tx_) == 0 {
+		return nil, fmt.Errorf("bad transaction")
+	}
+	if tx_.GetResult().GetCode() != 0 {
+		return nil, fmt.Errorf("%s", tx_.GetResult().GetMessage())
+	}
+	return tx.New(c.client, tx_.Transaction), nil
+}
+
+// UpdatePermissions replaces the permissions of the signer account, signed
+// by the signer alone. It costs the AccountPermissionUpdate fee of the
+// chain, 100 TRX on mainnet. See BuildPermissionUpdate for a multi-sig owner
+func (c *Client) UpdatePermissions(ctx context.Context, ps *Permissions) (*tx.Transaction, error) {
+	t, err := c.buildPermissionUpdate(ctx, c.getSignerAddress(), ps)
+	if err != nil {
+		return nil, err
+	}
+	return t, t.SignAndSend(ctx, c.getSigner())
+}
@@ -0,0 +1,140 @@
+package trx_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"github.com/fullstackwang/tron-grpc/trx"
+	"github.com/fullstackwang/tron-grpc/tx"
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+func marketPermissions(owner *wallet.Wallet) *trx.Permissions {
+	return &trx.Permissions{
+		Owner: trx.NewPermission("owner", 1).AddKey(owner.Address(), 1),
+		Actives: []*trx.Permission{
+			trx.NewPermission("market", 1).
+				AddKey(owner.Address(), 1).
+				Allow(core.Transaction_Contract_TransferContract, core.Transaction_Contract_MarketSellAssetContract),
+		},
+	}
+}
+
+func TestCheckOperations(t *testing.T) {
+	owner, _ := wallet.Generate()
+	ps := marketPermissions(owner)
+	if err := ps.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ps.CheckOperations(trx.DefaultAvailableContractTypes); !errors.Is(err, trx.ErrInvalidPermission) {
+		t.Fatalf("market operation before the proposal: got %v, want ErrInvalidPermission", err)
+	}
+	params := &core.ChainParameters{ChainParameter: []*core.ChainParameters_ChainParameter{
+		{Key: "getAllowMarketTransaction", Value: 1},
+	}}
+	if err := ps.CheckOperations(trx.AvailableContractTypes(params)); err != nil {
+		t.Fatalf("market operation after the proposal: %v", err)
+	}
+
+	ps.Actives[0].Allow(core.Transaction_Contract_AccountPermissionUpdateContract)
+	if err := ps.CheckOperations(trx.AvailableContractTypes(params)); err != nil {
+		t.Fatal(err)
+	}
+	ps.Actives[0].Allow(core.Transaction_Contract_ShieldedTransferContract)
+	if err := ps.CheckOperations(trx.AvailableContractTypes(params)); err == nil {
+		t.Fatal("shielded transfer accepted")
+	}
+}
+
+func TestUpdatePermissionsChecksChain(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	s.AutoMine = true
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	owner, _ := wallet.Generate()
+	s.SetBalance(owner.Address(), 1_000_000_000)
+	c.Signer = owner
+	tr := trx.New(c)
+
+	if _, err := tr.UpdatePermissions(context.Background(), marketPermissions(owner)); err != nil {
+		t.Fatal(err)
+	}
+	ps, err := tr.GetPermissions(context.Background(), owner.Address().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps.Actives) != 1 || len(ps.Actives[0].Operations) != 2 {
+		t.Fatalf("actives %+v", ps.Actives)
+	}
+
+	// a chain without the market proposal rejects the operation
+	s.Handle("GetChainParameters", func(context.Context, any) (any, error) {
+		return &core.ChainParameters{}, nil
+	})
+	_, err = tr.UpdatePermissions(context.Background(), marketPermissions(owner))
+	if !errors.Is(err, trx.ErrInvalidPermission) {
+		t.Fatalf("got %v, want ErrInvalidPermission", err)
+	}
+}
+
+func TestBuildPermissionUpdateMultiSig(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	s.AutoMine = true
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+	owner, _ := wallet.Generate()
+	cosigner, _ := wallet.Generate()
+	s.SetBalance(owner.Address(), 1_000_000_000)
+	c.Signer = owner
+	tr := trx.New(c)
+
+	// the owner permission needs both keys from now on
+	ps := marketPermissions(owner)
+	ps.Owner = trx.NewPermission("owner", 2).AddKey(owner.Address(), 1).AddKey(cosigner.Address(), 1)
+	if _, err := tr.UpdatePermissions(context.Background(), ps); err != nil {
+		t.Fatal(err)
+	}
+
+	// the signer alone cannot update them anymore
+	ps.Owner = trx.NewPermission("owner", 1).AddKey(cosigner.Address(), 1)
+	if _, err := tr.UpdatePermissions(context.Background(), ps); err == nil {
+		t.Fatal("update signed by one key of a 2-of-2 owner accepted")
+	}
+
+	built, err := tr.BuildPermissionUpdate(context.Background(), owner.Address().String(), ps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(built.Signature) != 0 {
+		t.Fatal("built transaction is signed")
+	}
+	m, err := tx.NewMultiSig(context.Background(), built, tx.OwnerPermissionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Sign(owner, cosigner); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got, err := tr.GetPermissions(context.Background(), owner.Address().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Owner.Keys) != 1 || !bytes.Equal(got.Owner.Keys[0].Address, cosigner.Address()) {
+		t.Fatalf("owner %+v", got.Owner)
+	}
+}
@@ -0,0 +1,173 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultBroadcastAttempts = 3
+	defaultBroadcastBackoff  = 500 * time.Millisecond
+)
+
+var (
+	ErrSignature        = fmt.Errorf("invalid signature")
+	ErrContractValidate = fmt.Errorf("contract validation failed")
+	ErrContractExecute  = fmt.Errorf("contract execution failed")
+	ErrBandwidth        = fmt.Errorf("not enough bandwidth")
+	ErrDuplicate        = fmt.Errorf("duplicate transaction")
+	ErrTapos            = fmt.Errorf("reference block not found")
+	ErrTooBig           = fmt.Errorf("transaction too big")
+	ErrServerBusy       = fmt.Errorf("server busy")
+	ErrNoConnection     = fmt.Errorf("node not connected to the network")
+)
+
+// codeErrors are the sentinels matched by a BroadcastError of each code
+var codeErrors = map[api.ReturnResponseCode]error{
+	api.Return_SIGERROR:                        ErrSignature,
+	api.Return_CONTRACT_VALIDATE_ERROR:         ErrContractValidate,
+	api.Return_CONTRACT_EXE_ERROR:              ErrContractExecute,
+	api.Return_BANDWITH_ERROR:                  ErrBandwidth,
+	api.Return_DUP_TRANSACTION_ERROR:           ErrDuplicate,
+	api.Return_TAPOS_ERROR:                     ErrTapos,
+	api.Return_TOO_BIG_TRANSACTION_ERROR:       ErrTooBig,
+	api.Return_TRANSACTION_EXPIRATION_ERROR:    ErrExpired,
+	api.Return_SERVER_BUSY:                     ErrServerBusy,
+	api.Return_NO_CONNECTION:                   ErrNoConnection,
+	api.Return_NOT_ENOUGH_EFFECTIVE_CONNECTION: ErrNoConnection,
+}
+
+// BroadcastError is a transaction rejected by a node. It matches the
+// sentinel of its code, e.g. ErrExpired or ErrDuplicate
+type BroadcastError struct {
+	Txid    []byte
+	Code    api.ReturnResponseCode
+	Message string
+	// Node is the node which rejected it, when known
+	Node string
+}
+
+func (e *BroadcastError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("broadcast %x: %s", e.Txid, e.Code)
+	}
+	return fmt.Sprintf("broadcast %x: %s: %s", e.Txid, e.Code, e.Message)
+}
+
+func (e *BroadcastError) Is(target error) bool {
+	err, ok := codeErrors[e.Code]
+	return ok && err == target
+}
+
+// Temporary reports whether another node, or the same one later, may
+// accept the transaction
+func (e *BroadcastError) Temporary() bool {
+	switch e.Code {
+	case api.Return_SERVER_BUSY, api.Return_NO_CONNECTION, api.Return_NOT_ENOUGH_EFFECTIVE_CONNECTION:
+		return true
+	}
+	return false
+}
+
+// NeedsRebuild reports whether err is a transaction which can no longer be
+// accepted, expired or on an unknown reference block. It must be built
+// again on a recent block and signed again
+func NeedsRebuild(err error) bool {
+	return errors.Is(err, ErrExpired) || errors.Is(err, ErrTapos)
+}
+
+// broadcastError returns the *BroadcastError of a failed ret, nil on success
+func broadcastError(txid []byte, ret *api.Return) error {
+	if ret.GetCode() == api.Return_SUCCESS {
+		return nil
+	}
+	return &BroadcastError{
+		Txid:    txid,
+		Code:    ret.Code,
+		Message: string(ret.Message),
+	}
+}
+
+// NodeSelector is a wallet client over several nodes which can be steered
+// to the other nodes, like *client.Client
+type NodeSelector interface {
+	// ExcludeNodes is a call option skipping the nodes at addresses
+	ExcludeNodes(addresses ...string) grpc.CallOption
+	// NodeUsed is a call option storing the node which served the call
+	NodeUsed(address *string) grpc.CallOption
+}
+
+// Broadcaster sends signed transactions. A duplicate is a success, since
+// the transaction is already known to the network, and a busy or badly
+// connected node is retried on another node of the pool
+type Broadcaster struct {
+	client api.WalletClient
+	// MaxAttempts is the number of broadcasts of a transaction, 3 by default
+	MaxAttempts int
+	// Backoff is the pause before a retry, 500ms by default
+	Backoff time.Duration
+}
+
+// NewBroadcaster returns a Broadcaster sending with c. The retries go to
+// other nodes when c is a NodeSelector with several nodes
+func NewBroadcaster(c api.WalletClient) *Broadcaster {
+	return &Broadcaster{client: c}
+}
+
+// Broadcast sends tx until a node accepts it. It returns a *BroadcastError
+// when the node rejects it, NeedsRebuild tells when it must be rebuilt
+func (b *Broadcaster) Broadcast(ctx context.Context, tx *Transaction) error {
+	attempts, backoff := b.MaxAttempts, b.Backoff
+	if attempts <= 0 {
+		attempts = defaultBroadcastAttempts
+	}
+	if backoff <= 0 {
+		backoff = defaultBroadcastBackoff
+	}
+	if len(tx.Txid) == 0 {
+		if err := tx.updateHash(); err != nil {
+			return err
+		}
+	}
+
+	selector, _ := b.client.(NodeSelector)
+	var tried []string
+	for attempt := 0; ; attempt++ {
+		var node string
+		var opts []grpc.CallOption
+		if selector != nil {
+			opts = append(opts, selector.ExcludeNodes(tried...), selector.NodeUsed(&node))
+		}
+		ret, err := b.client.BroadcastTransaction(ctx, tx.Transaction, opts...)
+		if err != nil {
+			return err
+		}
+		err = broadcastError(tx.Txid, ret)
+		if err == nil {
+			return nil
+		}
+		e := err.(*BroadcastError)
+		e.Node = node
+		if e.Code == api.Return_DUP_TRANSACTION_ERROR {
+			return nil
+		}
+		if !e.Temporary() || attempt+1 >= attempts {
+			return e
+		}
+		if node != "" {
+			tried = append(tried, node)
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return e
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package tx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/client"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"github.com/fullstackwang/tron-grpc/tx"
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+func busyServer(calls *int) *testutil.Server {
+	s := testutil.NewServer()
+	s.Handle("BroadcastTransaction", func(context.Context, any) (any, error) {
+		*calls++
+		return &api.Return{Code: api.Return_SERVER_BUSY, Message: []byte("busy")}, nil
+	})
+	return s
+}
+
+func newPoolClient(t *testing.T, servers map[string]*testutil.Server, endpoints ...string) *client.Client {
+	t.Helper()
+	c := client.NewWithEndpoints(endpoints, "")
+	c.SetStrategy(client.LeastLatency)
+	c.SetHealthCheck(0, 0)
+	if err := c.Start(testutil.PoolDialOptions(servers)...); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Stop)
+	return c
+}
+
+func signedTransfer(t *testing.T, s *testutil.Server, c api.WalletClient, expiration time.Time) *tx.Transaction {
+	t.Helper()
+	owner, _ := wallet.Generate()
+	to, _ := wallet.Generate()
+	s.SetBalance(owner.Address(), 1000)
+	head := s.ProduceBlock()
+	b := tx.NewBuilder(&core.TransferContract{OwnerAddress: owner.Address(), ToAddress: to.Address(), Amount: 5}).
+		RefBlockExtention(head)
+	if !expiration.IsZero() {
+		b = b.Timestamp(expiration.Add(-time.Second)).Expiration(expiration)
+	}
+	tt, err := b.Transaction(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tt.Sign(owner); err != nil {
+		t.Fatal(err)
+	}
+	return tt
+}
+
+func TestSendClassifiesErrors(t *testing.T) {
+	var calls int
+	busy := busyServer(&calls)
+	defer busy.Stop()
+	c := newPoolClient(t, map[string]*testutil.Server{"busy": busy}, "busy")
+	tt := signedTransfer(t, busy, c, time.Time{})
+
+	err := tt.Send(context.Background())
+	var be *tx.BroadcastError
+	if !errors.As(err, &be) || !errors.Is(err, tx.ErrServerBusy) || !be.Temporary() {
+		t.Fatalf("got %v", err)
+	}
+	if errors.Is(err, tx.ErrDuplicate) || tx.NeedsRebuild(err) {
+		t.Fatal("matches other codes")
+	}
+}
+
+func TestBroadcasterRetriesOtherNode(t *testing.T) {
+	var calls int
+	busy, ok := busyServer(&calls), testutil.NewServer()
+	defer busy.Stop()
+	defer ok.Stop()
+	c := newPoolClient(t, map[string]*testutil.Server{"busy": busy, "ok": ok}, "busy", "ok")
+	tt := signedTransfer(t, ok, c, time.Time{})
+
+	b := tx.NewBroadcaster(c)
+	b.Backoff = time.Millisecond
+	if err := b.Broadcast(context.Background(), tt); err != nil {
+		t.Fatal(err)
+	}
+	if len(ok.Pending()) != 1 || calls > 1 {
+		t.Fatalf("pending %d, busy calls %d", len(ok.Pending()), calls)
+	}
+
+	// the node knows it already
+	if err := b.Broadcast(context.Background(), tt); err != nil {
+		t.Fatal(err)
+	}
+	direct, _ := ok.Client()
+	defer direct.Stop()
+	if err := tx.New(direct, tt.Transaction).Send(context.Background()); !errors.Is(err, tx.ErrDuplicate) {
+		t.Fatalf("Send of a duplicate: %v", err)
+	}
+}
+
+func TestBroadcasterGivesUp(t *testing.T) {
+	var calls int
+	busy := busyServer(&calls)
+	defer busy.Stop()
+	c := newPoolClient(t, map[string]*testutil.Server{"busy": busy}, "busy")
+	tt := signedTransfer(t, busy, c, time.Time{})
+
+	b := tx.NewBroadcaster(c)
+	b.Backoff = time.Millisecond
+	err := b.Broadcast(context.Background(), tt)
+	var be *tx.BroadcastError
+	if !errors.As(err, &be) || be.Node != "busy" || calls != 3 {
+		t.Fatalf("got %v after %d calls", err, calls)
+	}
+}
+
+func TestBroadcasterReportsExpiration(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	c, _ := s.Client()
+	defer c.Stop()
+	tt := signedTransfer(t, s, c, time.UnixMilli(1000))
+
+	err := tx.NewBroadcaster(c).Broadcast(context.Background(), tt)
+	if !errors.Is(err, tx.ErrExpired) || !tx.NeedsRebuild(err) {
+		t.Fatalf("got %v", err)
+	}
+}
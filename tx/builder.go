@@ -0,0 +1,188 @@
+package tx
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// DefaultExpiration is the expiration the node gives to the transactions it
+// creates, counted from the reference block
+const DefaultExpiration = 60 * time.Second
+
+// BlockID returns the id of a block header, its number on 8 bytes followed
+// by the end of the hash of raw_data
+func BlockID(header *core.BlockHeader) ([]byte, error) {
+	raw := header.GetRawData()
+	if raw == nil {
+		return nil, fmt.Errorf("block header has no raw_data")
+	}
+	data, err := proto.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	id := sha256.Sum256(data)
+	binary.BigEndian.PutUint64(id[:8], uint64(raw.Number))
+	return id[:], nil
+}
+
+// Builder assembles the raw_data of a transaction without a node, the way
+// the node does in CreateTransaction2 and its siblings
+type Builder struct {
+	raw        *core.TransactionRaw
+	expiration time.Duration
+	refTime    int64
+	err        error
+}
+
+// NewBuilder starts a transaction for contract, the contract type is found
+// from the message name, e.g. *core.TransferContract is TransferContract
+func NewBuilder(contract proto.Message) *Builder {
+	name := string(proto.MessageV2(contract).ProtoReflect().Descriptor().Name())
+	typ, ok := core.Transaction_Contract_ContractType_value[name]
+	if !ok {
+		return &Builder{err: fmt.Errorf("no contract type for %s", name)}
+	}
+	return NewBuilderWithType(core.Transaction_Contract_ContractType(typ), contract)
+}
+
+// NewBuilderWithType starts a transaction for contract of type typ
+func NewBuilderWithType(typ core.Transaction_Contract_ContractType, contract proto.Message) *Builder {
+	b := &Builder{expiration: DefaultExpiration}
+	param, err := anypb.New(proto.MessageV2(contract))
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.raw = &core.TransactionRaw{
+		Contract: []*core.Transaction_Contract{{Type: typ, Parameter: param}},
+	}
+	return b
+}
+
+// RefBlock references a block by its header, usually the latest one. The
+// expiration is counted from its timestamp like the node does
+func (b *Builder) RefBlock(header *core.BlockHeader) *Builder {
+	if b.err != nil {
+		return b
+	}
+	id, err := BlockID(header)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.refTime = header.RawData.Timestamp
+	return b.RefBlockID(id)
+}
+
+// RefBlockExtention references a block returned by GetNowBlock2
+func (b *Builder) RefBlockExtention(block *api.BlockExtention) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if len(block.GetBlockid()) != 32 {
+		return b.RefBlock(block.GetBlockHeader())
+	}
+	b.refTime = block.GetBlockHeader().GetRawData().GetTimestamp()
+	return b.RefBlockID(block.Blockid)
+}
+
+// RefBlockID references a block by its 32 bytes id
+func (b *Builder) RefBlockID(id []byte) *Builder {
+	if b.err != nil {
+		return b
+	}
+	if len(id) != 32 {
+		b.err = fmt.Errorf("block id must be 32 bytes")
+		return b
+	}
+	b.raw.RefBlockBytes = append([]byte(nil), id[6:8]...)
+	b.raw.RefBlockHash = append([]byte(nil), id[8:16]...)
+	return b
+}
+
+// Expiration sets an absolute expiration
+func (b *Builder) Expiration(t time.Time) *Builder {
+	if b.raw != nil {
+		b.raw.Expiration = t.UnixMilli()
+	}
+	return b
+}
+
+// ExpireAfter sets the expiration relative to the reference block, or to
+// the timestamp without reference block time. It is 60s by default
+func (b *Builder) ExpireAfter(d time.Duration) *Builder {
+	b.expiration = d
+	return b
+}
+
+// Timestamp sets the creation time, now by default
+func (b *Builder) Timestamp(t time.Time) *Builder {
+	if b.raw != nil {
+		b.raw.Timestamp = t.UnixMilli()
+	}
+	return b
+}
+
+// FeeLimit sets the maximum fee in sun of a smart contract call
+func (b *Builder) FeeLimit(sun int64) *Builder {
+	if b.raw != nil {
+		b.raw.FeeLimit = sun
+	}
+	return b
+}
+
+// Data sets the memo of the transaction
+func (b *Builder) Data(data []byte) *Builder {
+	if b.raw != nil {
+		b.raw.Data = data
+	}
+	return b
+}
+
+// PermissionID sets the permission signing the transaction
+func (b *Builder) PermissionID(id int32) *Builder {
+	if b.raw != nil {
+		b.raw.Contract[0].PermissionId = id
+	}
+	return b
+}
+
+// Build returns the raw_data, a reference block is required
+func (b *Builder) Build() (*core.TransactionRaw, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.raw.RefBlockHash) == 0 {
+		return nil, fmt.Errorf("no reference block")
+	}
+	raw := proto.Clone(b.raw).(*core.TransactionRaw)
+	if raw.Timestamp == 0 {
+		raw.Timestamp = time.Now().UnixMilli()
+	}
+	if raw.Expiration == 0 {
+		base := b.refTime
+		if base == 0 {
+			base = raw.Timestamp
+		}
+		raw.Expiration = base + b.expiration.Milliseconds()
+	}
+	return raw, nil
+}
+
+// Transaction builds the transaction and its txid, client is used to send
+// it and may be nil for cold signing
+func (b *Builder) Transaction(client api.WalletClient) (*Transaction, error) {
+	raw, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	t := New(client, &core.Transaction{RawData: raw})
+	return t, t.updateHash()
+}
@@ -0,0 +1,141 @@
+package tx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"github.com/fullstackwang/tron-grpc/tx"
+	"github.com/fullstackwang/tron-grpc/wallet"
+	"github.com/golang/protobuf/proto"
+)
+
+// the offline builder must give the raw_data and txid the node gives
+func TestBuilderMatchesNode(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	s.ProduceBlock()
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	owner, _ := wallet.Generate()
+	to, _ := wallet.Generate()
+	s.SetBalance(owner.Address(), 1000)
+	transfer := &core.TransferContract{OwnerAddress: owner.Address(), ToAddress: to.Address(), Amount: 5}
+	ext, err := c.CreateTransaction2(context.Background(), transfer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := c.GetNowBlock2(context.Background(), &api.EmptyMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	timestamp := time.UnixMilli(ext.Transaction.RawData.Timestamp)
+
+	for name, b := range map[string]*tx.Builder{
+		"header":     tx.NewBuilder(transfer).RefBlock(head.BlockHeader),
+		"extention":  tx.NewBuilder(transfer).RefBlockExtention(head),
+		"expiration": tx.NewBuilder(transfer).RefBlockID(head.Blockid).Expiration(time.UnixMilli(ext.Transaction.RawData.Expiration)),
+	} {
+		built, err := b.Timestamp(timestamp).Transaction(nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !proto.Equal(built.RawData, ext.Transaction.RawData) {
+			t.Fatalf("%s: raw_data %v, want %v", name, built.RawData, ext.Transaction.RawData)
+		}
+		if !bytes.Equal(built.Txid, ext.Txid) {
+			t.Fatalf("%s: txid %x, want %x", name, built.Txid, ext.Txid)
+		}
+	}
+
+	// a cold signed transaction is accepted by the node
+	s.AutoMine = true
+	built, err := tx.NewBuilder(transfer).RefBlock(head.BlockHeader).Transaction(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := built.SignAndSend(context.Background(), owner); err != nil {
+		t.Fatal(err)
+	}
+	if s.Balance(to.Address()) != 5 {
+		t.Fatalf("balance %d, want 5", s.Balance(to.Address()))
+	}
+}
+
+func TestBuilderRequiresRefBlock(t *testing.T) {
+	if _, err := tx.NewBuilder(&core.TransferContract{}).Build(); err == nil {
+		t.Fatal("built without a reference block")
+	}
+	if _, err := tx.NewBuilder(&core.Account{}).RefBlockID(make([]byte, 32)).Build(); err == nil {
+		t.Fatal("built a transaction of a message which is not a contract")
+	}
+}
+
+// a nile-like block header and a transfer referencing it, encoded by hand
+// from the protobuf wire format of java-tron, the ids are sha256 of these
+// bytes. Builder and BlockID must reproduce them byte for byte
+const (
+	vectorHeaderRaw = "08e8bf9e868032122000000000000000000000000000000000000000000000000000000000000000001a200000000002904b3f5c1d7c0c61ba1bc8d3f8a3b7d9f0e6a4c2b1e8f7a6d5c4b338c096c1144a15418a4c6e3b2d1f0e9a8b7c6d5e4f3a2b1c0d9e8f7a501e"
+	vectorBlockID   = "0000000002904b40d40a078695cf47d749faf171d8c4b24ad3e444a4393fc2fd"
+	vectorRawData   = "0a024b402208d40a078695cf47d740c894a28680325a67080112630a2d747970652e676f6f676c65617069732e636f6d2f70726f746f636f6c2e5472616e73666572436f6e747261637412320a1541c5c0f2c3d0a8f8dd1a1d5e2e5b0c3c8fa8c8e1b21215411b7dfb8a5c3e2f0c4a9d6e7f8091a2b3c4d5e6f718c0843d70a9ca9e868032"
+	vectorTxid      = "29b187c2cfc03a70e88a6623431eb7fa7159471b9fbaae0da0e9f9e93e7536f9"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestBuilderVector(t *testing.T) {
+	headerRaw := new(core.BlockHeaderRaw)
+	if err := proto.Unmarshal(mustHex(t, vectorHeaderRaw), headerRaw); err != nil {
+		t.Fatal(err)
+	}
+	header := &core.BlockHeader{RawData: headerRaw}
+	id, err := tx.BlockID(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(id) != vectorBlockID {
+		t.Fatalf("block id %x, want %s", id, vectorBlockID)
+	}
+
+	transfer := &core.TransferContract{
+		OwnerAddress: mustHex(t, "41c5c0f2c3d0a8f8dd1a1d5e2e5b0c3c8fa8c8e1b2"),
+		ToAddress:    mustHex(t, "411b7dfb8a5c3e2f0c4a9d6e7f8091a2b3c4d5e6f7"),
+		Amount:       1000000,
+	}
+	for name, b := range map[string]*tx.Builder{
+		"header":    tx.NewBuilder(transfer).RefBlock(header),
+		"extention": tx.NewBuilder(transfer).RefBlockExtention(&api.BlockExtention{BlockHeader: header, Blockid: id}),
+		"id":        tx.NewBuilder(transfer).RefBlockID(id).Expiration(time.UnixMilli(headerRaw.Timestamp).Add(tx.DefaultExpiration)),
+	} {
+		built, err := b.Timestamp(time.UnixMilli(1718000002345)).Transaction(nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		raw, err := proto.Marshal(built.RawData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(raw) != vectorRawData {
+			t.Errorf("%s: raw_data %x, want %s", name, raw, vectorRawData)
+		}
+		if hex.EncodeToString(built.Txid) != vectorTxid {
+			t.Errorf("%s: txid %x, want %s", name, built.Txid, vectorTxid)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package tx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fullstackwang/tron-grpc/abi"
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/golang/protobuf/proto"
+)
+
+// SunPerTRX is the number of sun in 1 TRX
+const SunPerTRX = 1_000_000
+
+// sunFields are the fields in sun of each contract, the other amounts are
+// in token units. A nested field is given by its path, like
+// new_contract.call_value
+var sunFields = map[string][]string{
+	"TransferContract":              {"amount"},
+	"ParticipateAssetIssueContract": {"amount"},
+	"FreezeBalanceContract":         {"frozen_balance"},
+	"CreateSmartContract":           {"new_contract.call_value"},
+	"TriggerSmartContract":          {"call_value"},
+}
+
+// Amount is an amount of TRX
+type Amount struct {
+	Sun int64  `json:"sun"`
+	TRX string `json:"trx"`
+}
+
+// NewAmount returns the amount of sun
+func NewAmount(sun int64) Amount {
+	return Amount{Sun: sun, TRX: FormatTRX(sun)}
+}
+
+func (a Amount) String() string {
+	return fmt.Sprintf("%s TRX (%d sun)", a.TRX, a.Sun)
+}
+
+// FormatTRX formats sun in TRX with the 6 decimals
+func FormatTRX(sun int64) string {
+	return new(big.Rat).SetFrac64(sun, SunPerTRX).FloatString(6)
+}
+
+// CallArg is a decoded argument of a smart contract call
+type CallArg struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// Call is the decoded calldata of a TriggerSmartContract. When the
+// arguments do not match the ABI, Data holds the raw calldata instead
+type Call struct {
+	Method string    `json:"method"`
+	Args   []CallArg `json:"args"`
+	Data   string    `json:"data,omitempty"`
+}
+
+// ContractSummary is one contract of a transaction
+type ContractSummary struct {
+	Type         string         `json:"type"`
+	PermissionID int32          `json:"permission_id,omitempty"`
+	Fields       map[string]any `json:"fields"`
+	Call         *Call          `json:"call,omitempty"`
+	// Message is the typed contract, e.g. *core.TransferContract
+	Message proto.Message `json:"-"`
+}
+
+// Summary is a readable view of a transaction, for logs and approvals
+type Summary struct {
+	TxID       string            `json:"txid"`
+	Timestamp  time.Time         `json:"timestamp"`
+	Expiration time.Time         `json:"expiration"`
+	FeeLimit   *Amount           `json:"fee_limit,omitempty"`
+	Memo       string            `json:"memo,omitempty"`
+	Signatures int               `json:"signatures"`
+	Contracts  []ContractSummary `json:"contracts"`
+}
+
+// Decoder decodes transactions, with the ABIs registered for their
+// contract addresses. It is safe for concurrent use
+type Decoder struct {
+	mu   sync.RWMutex
+	abis map[string]*abi.Interface
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{abis: make(map[string]*abi.Interface)}
+}
+
+// RegisterABI decodes the calls to the contract at addr with the JSON ABI
+func (d *Decoder) RegisterABI(addr address.Address, abiJSON []byte) error {
+	iface, err := abi.Parse(abiJSON)
+	if err != nil {
+		return err
+	}
+	d.RegisterInterface(addr, iface)
+	return nil
+}
+
+// RegisterInterface is RegisterABI with a parsed ABI
+func (d *Decoder) RegisterInterface(addr address.Address, iface *abi.Interface) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.abis[string(addr)] = iface
+}
+
+func (d *Decoder) getInterface(addr []byte) *abi.Interface {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.abis[string(addr)]
+}
+
+// UnpackContracts returns the typed contracts of t from the core package
+func UnpackContracts(t *core.Transaction) ([]proto.Message, error) {
+	var msgs []proto.Message
+	for _, c := range t.GetRawData().GetContract() {
+		msg, err := c.GetParameter().UnmarshalNew()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.Type, err)
+		}
+		msgs = append(msgs, proto.MessageV1(msg))
+	}
+	return msgs, nil
+}
+
+// Decode returns the summary of t, addresses in base58 and amounts in TRX
+func (d *Decoder) Decode(t *core.Transaction) (*Summary, error) {
+	raw := t.GetRawData()
+	data, err := proto.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	txid := sha256.Sum256(data)
+	s := &Summary{
+		TxID:       hex.EncodeToString(txid[:]),
+		Timestamp:  time.UnixMilli(raw.GetTimestamp()),
+		Expiration: time.UnixMilli(raw.GetExpiration()),
+		Signatures: len(t.GetSignature()),
+	}
+	if raw.GetFeeLimit() > 0 {
+		feeLimit := NewAmount(raw.FeeLimit)
+		s.FeeLimit = &feeLimit
+	}
+	if len(raw.GetData()) > 0 {
+		s.Memo = printable(raw.Data)
+	}
+
+	msgs, err := UnpackContracts(t)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range raw.GetContract() {
+		cs, err := d.decodeContract(c, msgs[i])
+		if err != nil {
+			return nil, err
+		}
+		s.Contracts = append(s.Contracts, *cs)
+	}
+	return s, nil
+}
+
+func (d *Decoder) decodeContract(c *core.Transaction_Contract, msg proto.Message) (*ContractSummary, error) {
+	fields, err := messageToJSON(proto.MessageV2(msg).ProtoReflect(), true)
+	if err != nil {
+		return nil, err
+	}
+	name := string(proto.MessageV2(msg).ProtoReflect().Descriptor().Name())
+	for _, path := range sunFields[name] {
+		setAmount(fields, strings.Split(path, "."))
+	}
+	cs := &ContractSummary{
+		Type:         c.Type.String(),
+		PermissionID: c.PermissionId,
+		Fields:       fields,
+		Message:      msg,
+	}
+
+	trigger, ok := msg.(*core.TriggerSmartContract)
+	if !ok || len(trigger.Data) < 4 {
+		return cs, nil
+	}
+	iface := d.getInterface(trigger.ContractAddress)
+	if iface == nil {
+		return cs, nil
+	}
+	m := iface.MethodBySig(trigger.Data[:4])
+	if m == nil {
+		return cs, nil
+	}
+	cs.Call = &Call{Method: m.Signature}
+	args, err := m.DecodeInput(trigger.Data)
+	if err != nil {
+		// the ABI does not fit, e.g. a wrong one was registered
+		cs.Call.Data = "0x" + hex.EncodeToString(trigger.Data)
+		return cs, nil
+	}
+	for i, arg := range args {
+		cs.Call.Args = append(cs.Call.Args, CallArg{
+			Name:  m.InputNames[i],
+			Type:  m.InputTypes[i],
+			Value: readableArg(m.InputTypes[i], arg),
+		})
+	}
+	return cs, nil
+}
+
+// setAmount replaces the amount in sun at path with an Amount
+func setAmount(fields map[string]any, path []string) {
+	if len(path) > 1 {
+		if sub, ok := fields[path[0]].(map[string]any); ok {
+			setAmount(sub, path[1:])
+		}
+		return
+	}
+	if sun, ok := fields[path[0]].(int64); ok {
+		fields[path[0]] = NewAmount(sun)
+	}
+}
+
+// readableArg shows the addresses in base58, the big numbers in decimal and
+// the bytes in hex, in arrays and tuples too
+func readableArg(typ string, v any) any {
+	switch v := v.(type) {
+	case address.Address:
+		return v.String()
+	case []byte:
+		if typ == "address" && len(v) == address.LengthEthAddress {
+			return address.Address(append([]byte{address.TronBytePrefix}, v...)).String()
+		}
+		return "0x" + hex.EncodeToString(v)
+	case *big.Int:
+		return v.String()
+	case []any:
+		elemTypes := make([]string, len(v))
+		if i := strings.LastIndex(typ, "["); i > 0 && strings.HasSuffix(typ, "]") {
+			for j := range elemTypes {
+				elemTypes[j] = typ[:i]
+			}
+		} else if components := tupleTypes(typ); len(components) == len(v) {
+			elemTypes = components
+		}
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = readableArg(elemTypes[i], elem)
+		}
+		return out
+	}
+	return v
+}
+
+// tupleTypes returns the component types of a tuple type like
+// (address,(uint256,bytes)), nil for another type
+func tupleTypes(typ string) []string {
+	if !strings.HasPrefix(typ, "(") || !strings.HasSuffix(typ, ")") {
+		return nil
+	}
+	inner := typ[1 : len(typ)-1]
+	var types []string
+	depth, start := 0, 0
+	for i, c := range inner {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				types = append(types, inner[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(types, inner[start:])
+}
+
+// printable returns data as text when it is, else in hex
+func printable(data []byte) string {
+	if utf8.Valid(data) {
+		return string(data)
+	}
+	return "0x" + hex.EncodeToString(data)
+}
+
+// String renders the summary for a log or an approval screen
+func (s *Summary) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "txid:       %s\n", s.TxID)
+	fmt.Fprintf(&b, "timestamp:  %s\n", s.Timestamp.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "expiration: %s\n", s.Expiration.UTC().Format(time.RFC3339))
+	if s.FeeLimit != nil {
+		fmt.Fprintf(&b, "fee limit:  %s\n", s.FeeLimit)
+	}
+	if s.Memo != "" {
+		fmt.Fprintf(&b, "memo:       %q\n", s.Memo)
+	}
+	fmt.Fprintf(&b, "signatures: %d\n", s.Signatures)
+	for _, c := range s.Contracts {
+		fmt.Fprintf(&b, "%s", c.Type)
+		if c.PermissionID != 0 {
+			fmt.Fprintf(&b, " (permission %d)", c.PermissionID)
+		}
+		b.WriteString("\n")
+		writeFields(&b, "  ", c.Fields)
+		if c.Call != nil {
+			fmt.Fprintf(&b, "  call: %q\n", c.Call.Method)
+			if c.Call.Data != "" {
+				fmt.Fprintf(&b, "    undecodable data: %q\n", c.Call.Data)
+			}
+			for _, arg := range c.Call.Args {
+				fmt.Fprintf(&b, "    %s %s: %s\n", arg.Type, arg.Name, formatValue(arg.Value))
+			}
+		}
+	}
+	return b.String()
+}
+
+func writeFields(b *strings.Builder, indent string, fields map[string]any) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if sub, ok := fields[name].(map[string]any); ok {
+			fmt.Fprintf(b, "%s%s:\n", indent, name)
+			writeFields(b, indent+"  ", sub)
+			continue
+		}
+		fmt.Fprintf(b, "%s%s: %s\n", indent, name, formatValue(fields[name]))
+	}
+}
+
+// formatValue quotes the strings, which may hold any text of the
+// transaction, so they cannot fake other lines of the summary
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case fmt.Stringer:
+		return v.String()
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,156 @@
+package tx_test
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/abi"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/tx"
+	"github.com/fullstackwang/tron-grpc/wallet"
+	"github.com/golang/protobuf/proto"
+)
+
+const transferABI = `[{"type": "function", "name": "transfer", "inputs": [
+	{"name": "to", "type": "address"},
+	{"name": "value", "type": "uint256"}
+]}]`
+
+func buildTx(t *testing.T, contract proto.Message) *core.Transaction {
+	t.Helper()
+	tt, err := tx.NewBuilder(contract).
+		RefBlockID(bytes.Repeat([]byte{1}, 32)).
+		Timestamp(time.UnixMilli(1_700_000_000_000)).
+		Transaction(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tt.Transaction
+}
+
+func TestDecodeCreateSmartContractCallValue(t *testing.T) {
+	owner, _ := wallet.Generate()
+	s, err := tx.NewDecoder().Decode(buildTx(t, &core.CreateSmartContract{
+		OwnerAddress: owner.Address(),
+		NewContract:  &core.SmartContract{OriginAddress: owner.Address(), CallValue: 2_500_000},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	nested, _ := s.Contracts[0].Fields["new_contract"].(map[string]any)
+	if got := nested["call_value"]; got != tx.NewAmount(2_500_000) {
+		t.Fatalf("call_value %v", got)
+	}
+}
+
+func TestDecodeCall(t *testing.T) {
+	owner, _ := wallet.Generate()
+	token, _ := wallet.Generate()
+	to, _ := wallet.Generate()
+	iface, err := abi.Parse([]byte(transferABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := iface.Methods[0].InputEncoder.Encode([]any{to.Address(), big.NewInt(7)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append(iface.Methods[0].Sig, args...)
+	d := tx.NewDecoder()
+	if err := d.RegisterABI(token.Address(), []byte(transferABI)); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := d.Decode(buildTx(t, &core.TriggerSmartContract{OwnerAddress: owner.Address(), ContractAddress: token.Address(), Data: data}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := s.Contracts[0].Call
+	if call == nil || call.Method != "transfer(address,uint256)" || call.Args[0].Value != to.Address().String() || call.Args[1].Value != "7" {
+		t.Fatalf("call %+v", call)
+	}
+
+	// calldata not matching the ABI keeps the rest of the summary
+	s, err = d.Decode(buildTx(t, &core.TriggerSmartContract{OwnerAddress: owner.Address(), ContractAddress: token.Address(), Data: data[:20]}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call = s.Contracts[0].Call
+	if call == nil || len(call.Args) != 0 || !strings.HasPrefix(call.Data, "0x") {
+		t.Fatalf("call %+v", call)
+	}
+	if !strings.Contains(s.String(), "undecodable data") {
+		t.Fatal(s)
+	}
+}
+
+func TestDecodeTupleAddress(t *testing.T) {
+	owner, _ := wallet.Generate()
+	token, _ := wallet.Generate()
+	to, _ := wallet.Generate()
+	const orderABI = `[{"type": "function", "name": "fill", "inputs": [
+		{"name": "order", "type": "tuple", "components": [
+			{"name": "maker", "type": "address"},
+			{"name": "amount", "type": "uint256"}
+		]},
+		{"name": "takers", "type": "address[]"}
+	]}]`
+	iface, err := abi.Parse([]byte(orderABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := iface.Methods[0]
+	args, err := m.InputEncoder.Encode([]any{
+		[]any{to.Address(), big.NewInt(9)},
+		[]any{owner.Address()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := tx.NewDecoder()
+	d.RegisterInterface(token.Address(), iface)
+	s, err := d.Decode(buildTx(t, &core.TriggerSmartContract{
+		OwnerAddress:    owner.Address(),
+		ContractAddress: token.Address(),
+		Data:            append(m.Sig, args...),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := s.Contracts[0].Call
+	if call == nil || len(call.Args) != 2 {
+		t.Fatalf("call %+v", call)
+	}
+	order, _ := call.Args[0].Value.([]any)
+	if len(order) != 2 || order[0] != to.Address().String() || order[1] != "9" {
+		t.Fatalf("order %v", call.Args[0].Value)
+	}
+	takers, _ := call.Args[1].Value.([]any)
+	if len(takers) != 1 || takers[0] != owner.Address().String() {
+		t.Fatalf("takers %v", call.Args[1].Value)
+	}
+}
+
+// the strings of a transaction are quoted, so they cannot fake the lines
+// of an approval screen
+func TestSummaryQuotesStrings(t *testing.T) {
+	owner, _ := wallet.Generate()
+	fake := "x\nTransferContract\n  amount: 1.000000 TRX"
+	s, err := tx.NewDecoder().Decode(buildTx(t, &core.AccountUpdateContract{
+		OwnerAddress: owner.Address(),
+		AccountName:  []byte(fake),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := s.String()
+	if strings.Contains(out, "\nTransferContract") {
+		t.Fatalf("unquoted field:\n%s", out)
+	}
+	if !strings.Contains(out, `owner_address: "`+owner.Address().String()+`"`) {
+		t.Fatalf("unquoted address:\n%s", out)
+	}
+}
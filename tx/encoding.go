@@ -0,0 +1,430 @@
+package tx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var ErrTxidMismatch = fmt.Errorf("txID does not match raw_data")
+
+// jsonTransaction is the transaction format of the java-tron HTTP API
+type jsonTransaction struct {
+	Visible    bool            `json:"visible"`
+	TxID       string          `json:"txID"`
+	RawData    json.RawMessage `json:"raw_data"`
+	RawDataHex string          `json:"raw_data_hex"`
+	Signature  []string        `json:"signature,omitempty"`
+}
+
+// MarshalJSON encodes the transaction like the java-tron HTTP API, with hex
+// addresses
+func (tx *Transaction) MarshalJSON() ([]byte, error) {
+	return tx.EncodeJSON(false)
+}
+
+// EncodeJSON encodes the transaction like the java-tron HTTP API. With
+// visible the addresses are base58, like with "visible": true
+func (tx *Transaction) EncodeJSON(visible bool) ([]byte, error) {
+	rawData, err := proto.Marshal(tx.GetRawData())
+	if err != nil {
+		return nil, err
+	}
+	// the txid of raw_data as it is, tx.Txid is left to Sign
+	txid := sha256.Sum256(rawData)
+	raw, err := messageToJSON(proto.MessageV2(tx.GetRawData()).ProtoReflect(), visible)
+	if err != nil {
+		return nil, err
+	}
+	j := jsonTransaction{
+		Visible:    visible,
+		TxID:       hex.EncodeToString(txid[:]),
+		RawDataHex: hex.EncodeToString(rawData),
+	}
+	if j.RawData, err = json.Marshal(raw); err != nil {
+		return nil, err
+	}
+	for _, sig := range tx.Signature {
+		j.Signature = append(j.Signature, hex.EncodeToString(sig))
+	}
+	return json.Marshal(&j)
+}
+
+// UnmarshalJSON decodes a transaction of the java-tron HTTP API
+func (tx *Transaction) UnmarshalJSON(data []byte) error {
+	var j jsonTransaction
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	t := new(core.Transaction)
+	t.RawData = new(core.TransactionRaw)
+	if j.RawDataHex != "" {
+		// raw_data_hex holds the signed bytes, raw_data is only checked
+		rawData, err := hex.DecodeString(j.RawDataHex)
+		if err != nil {
+			return fmt.Errorf("raw_data_hex: %w", err)
+		}
+		if err := proto.Unmarshal(rawData, t.RawData); err != nil {
+			return fmt.Errorf("raw_data_hex: %w", err)
+		}
+		if len(j.RawData) > 0 {
+			fromJSON := new(core.TransactionRaw)
+			if err := decodeRawData(j.RawData, fromJSON, j.Visible); err != nil {
+				return err
+			}
+			if !proto.Equal(fromJSON, t.RawData) {
+				return fmt.Errorf("raw_data does not match raw_data_hex")
+			}
+		}
+	} else if err := decodeRawData(j.RawData, t.RawData, j.Visible); err != nil {
+		return err
+	}
+	for _, s := range j.Signature {
+		sig, err := hex.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("signature: %w", err)
+		}
+		t.Signature = append(t.Signature, sig)
+	}
+
+	tx.Transaction = t
+	if err := tx.updateHash(); err != nil {
+		return err
+	}
+	if j.TxID != "" && !strings.EqualFold(j.TxID, hex.EncodeToString(tx.Txid)) {
+		return ErrTxidMismatch
+	}
+	return nil
+}
+
+func decodeRawData(data json.RawMessage, raw *core.TransactionRaw, visible bool) error {
+	if len(data) == 0 {
+		return fmt.Errorf("no raw_data")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v map[string]any
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	if err := jsonToMessage(v, proto.MessageV2(raw).ProtoReflect(), visible); err != nil {
+		return fmt.Errorf("raw_data: %w", err)
+	}
+	return nil
+}
+
+// DecodeJSON decodes a transaction of the java-tron HTTP API, client is
+// used to send it and may be nil
+func DecodeJSON(client api.WalletClient, data []byte) (*Transaction, error) {
+	tx := New(client, nil)
+	if err := tx.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// EncodeProto returns the protobuf encoding of the transaction with its
+// signatures, the compact form
+func (tx *Transaction) EncodeProto() ([]byte, error) {
+	return proto.Marshal(tx.Transaction)
+}
+
+// EncodeHex returns the hex of EncodeProto
+func (tx *Transaction) EncodeHex() (string, error) {
+	data, err := tx.EncodeProto()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// DecodeProto decodes the output of EncodeProto
+func DecodeProto(client api.WalletClient, data []byte) (*Transaction, error) {
+	t := new(core.Transaction)
+	if err := proto.Unmarshal(data, t); err != nil {
+		return nil, err
+	}
+	if t.RawData == nil {
+		return nil, fmt.Errorf("transaction has no raw_data")
+	}
+	tx := New(client, t)
+	return tx, tx.updateHash()
+}
+
+// DecodeHex decodes the output of EncodeHex
+func DecodeHex(client api.WalletClient, s string) (*Transaction, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, err
+	}
+	return DecodeProto(client, data)
+}
+
+// isAddress tells the fields shown in base58 when visible
+func isAddress(fd protoreflect.FieldDescriptor) bool {
+	return fd.Kind() == protoreflect.BytesKind && strings.HasSuffix(string(fd.Name()), "address")
+}
+
+// nameFields are the bytes fields java-tron shows as UTF-8 text when
+// visible, the contract fields of HttpSelfFormatFieldName
+var nameFields = map[protoreflect.FullName]bool{
+	"protocol.AccountUpdateContract.account_name":       true,
+	"protocol.SetAccountIdContract.account_id":          true,
+	"protocol.AssetIssueContract.name":                  true,
+	"protocol.AssetIssueContract.abbr":                  true,
+	"protocol.AssetIssueContract.description":           true,
+	"protocol.AssetIssueContract.url":                   true,
+	"protocol.ParticipateAssetIssueContract.asset_name": true,
+	"protocol.TransferAssetContract.asset_name":         true,
+	"protocol.UpdateAssetContract.description":          true,
+	"protocol.UpdateAssetContract.url":                  true,
+	"protocol.WitnessCreateContract.url":                true,
+	"protocol.WitnessUpdateContract.update_url":         true,
+	"protocol.ExchangeCreateContract.first_token_id":    true,
+	"protocol.ExchangeCreateContract.second_token_id":   true,
+	"protocol.ExchangeInjectContract.token_id":          true,
+	"protocol.ExchangeWithdrawContract.token_id":        true,
+	"protocol.ExchangeTransactionContract.token_id":     true,
+	"protocol.MarketSellAssetContract.sell_token_id":    true,
+	"protocol.MarketSellAssetContract.buy_token_id":     true,
+}
+
+// isName tells the fields shown as text when visible
+func isName(fd protoreflect.FieldDescriptor) bool {
+	return fd.Kind() == protoreflect.BytesKind && nameFields[fd.FullName()]
+}
+
+// messageToJSON converts m like the JsonFormat of java-tron: proto field
+// names, bytes in hex, enums by name, int64 as numbers and Any as
+// {"value", "type_url"}. When visible, addresses are base58 and names text
+func messageToJSON(m protoreflect.Message, visible bool) (map[string]any, error) {
+	out := make(map[string]any)
+	if any_, ok := m.Interface().(*anypb.Any); ok {
+		msg, err := any_.UnmarshalNew()
+		if err != nil {
+			return nil, err
+		}
+		value, err := messageToJSON(msg.ProtoReflect(), visible)
+		if err != nil {
+			return nil, err
+		}
+		out["type_url"] = any_.TypeUrl
+		out["value"] = value
+		return out, nil
+	}
+
+	var err error
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		name := string(fd.Name())
+		switch {
+		case fd.IsMap():
+			err = fmt.Errorf("map field %s not supported", name)
+			return false
+		case fd.IsList():
+			list := v.List()
+			items := make([]any, list.Len())
+			for i := range items {
+				if items[i], err = valueToJSON(fd, list.Get(i), visible); err != nil {
+					return false
+				}
+			}
+			out[name] = items
+		default:
+			if out[name], err = valueToJSON(fd, v, visible); err != nil {
+				return false
+			}
+		}
+		return true
+	})
+	return out, err
+}
+
+func valueToJSON(fd protoreflect.FieldDescriptor, v protoreflect.Value, visible bool) (any, error) {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return messageToJSON(v.Message(), visible)
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+		return int32(v.Enum()), nil
+	case protoreflect.BytesKind:
+		b := v.Bytes()
+		if visible && isAddress(fd) && len(b) == address.Length {
+			return address.Address(b).String(), nil
+		}
+		if visible && isName(fd) {
+			return string(b), nil
+		}
+		return hex.EncodeToString(b), nil
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f := v.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return strconv.FormatFloat(f, 'g', -1, 64), nil
+		}
+		return f, nil
+	}
+	return v.Interface(), nil
+}
+
+// jsonToMessage is the reverse of messageToJSON, it accepts the numbers as
+// strings too
+func jsonToMessage(in map[string]any, m protoreflect.Message, visible bool) error {
+	if _, ok := m.Interface().(*anypb.Any); ok {
+		return jsonToAny(in, m, visible)
+	}
+	fields := m.Descriptor().Fields()
+	for name, v := range in {
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil {
+			fd = fields.ByJSONName(name)
+		}
+		if fd == nil {
+			return fmt.Errorf("unknown field %s in %s", name, m.Descriptor().FullName())
+		}
+		if v == nil {
+			continue
+		}
+		switch {
+		case fd.IsMap():
+			return fmt.Errorf("map field %s not supported", name)
+		case fd.IsList():
+			items, ok := v.([]any)
+			if !ok {
+				return fmt.Errorf("%s: expected an array", name)
+			}
+			list := m.Mutable(fd).List()
+			for _, item := range items {
+				if fd.Kind() == protoreflect.MessageKind {
+					elem := list.NewElement()
+					obj, ok := item.(map[string]any)
+					if !ok {
+						return fmt.Errorf("%s: expected an object", name)
+					}
+					if err := jsonToMessage(obj, elem.Message(), visible); err != nil {
+						return err
+					}
+					list.Append(elem)
+					continue
+				}
+				value, err := jsonToValue(fd, item, visible)
+				if err != nil {
+					return fmt.Errorf("%s: %w", name, err)
+				}
+				list.Append(value)
+			}
+		case fd.Kind() == protoreflect.MessageKind:
+			obj, ok := v.(map[string]any)
+			if !ok {
+				return fmt.Errorf("%s: expected an object", name)
+			}
+			if err := jsonToMessage(obj, m.Mutable(fd).Message(), visible); err != nil {
+				return err
+			}
+		default:
+			value, err := jsonToValue(fd, v, visible)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			m.Set(fd, value)
+		}
+	}
+	return nil
+}
+
+func jsonToAny(in map[string]any, m protoreflect.Message, visible bool) error {
+	typeURL, _ := in["type_url"].(string)
+	mt, err := protoregistry.GlobalTypes.FindMessageByURL(typeURL)
+	if err != nil {
+		return fmt.Errorf("type_url %q: %w", typeURL, err)
+	}
+	msg := mt.New()
+	if value, ok := in["value"].(map[string]any); ok {
+		if err := jsonToMessage(value, msg, visible); err != nil {
+			return err
+		}
+	}
+	value, err := proto.Marshal(proto.MessageV1(msg.Interface()))
+	if err != nil {
+		return err
+	}
+	any_ := m.Interface().(*anypb.Any)
+	any_.TypeUrl = typeURL
+	any_.Value = value
+	return nil
+}
+
+func jsonToValue(fd protoreflect.FieldDescriptor, v any, visible bool) (protoreflect.Value, error) {
+	s, isString := v.(string)
+	if n, ok := v.(json.Number); ok {
+		s = n.String()
+	}
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		if !isString {
+			return protoreflect.Value{}, fmt.Errorf("expected a string")
+		}
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		if !isString {
+			return protoreflect.Value{}, fmt.Errorf("expected a string")
+		}
+		if visible && isAddress(fd) && len(s) == address.LengthBase58 {
+			addr, err := address.FromBase58(s)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfBytes(addr), nil
+		}
+		if visible && isName(fd) {
+			return protoreflect.ValueOfBytes([]byte(s)), nil
+		}
+		b, err := hex.DecodeString(s)
+		return protoreflect.ValueOfBytes(b), err
+	case protoreflect.BoolKind:
+		b, ok := v.(bool)
+		if !ok {
+			return protoreflect.Value{}, fmt.Errorf("expected a bool")
+		}
+		return protoreflect.ValueOfBool(b), nil
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported kind %s", fd.Kind())
+}
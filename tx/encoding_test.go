@@ -0,0 +1,151 @@
+package tx_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/tx"
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+func newTransfer(t *testing.T) (*wallet.Wallet, *tx.Transaction) {
+	t.Helper()
+	owner, err := wallet.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, _ := wallet.Generate()
+	now := time.UnixMilli(1_700_000_000_000)
+	tt, err := tx.NewBuilder(&core.TransferContract{OwnerAddress: owner.Address(), ToAddress: to.Address(), Amount: 5}).
+		RefBlockID(bytes.Repeat([]byte{1}, 32)).
+		Timestamp(now).
+		Expiration(now.Add(time.Minute)).
+		Transaction(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return owner, tt
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	owner, tt := newTransfer(t)
+	if err := tt.Sign(owner); err != nil {
+		t.Fatal(err)
+	}
+	for _, visible := range []bool{false, true} {
+		data, err := tt.EncodeJSON(visible)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := tx.DecodeJSON(nil, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got.Txid, tt.Txid) || len(got.Signature) != 1 {
+			t.Fatalf("visible %v: decoded %x", visible, got.Txid)
+		}
+	}
+}
+
+func TestEncodeDoesNotBlessTampering(t *testing.T) {
+	owner, tt := newTransfer(t)
+	if err := tt.Sign(owner); err != nil {
+		t.Fatal(err)
+	}
+	txid := append([]byte(nil), tt.Txid...)
+	tt.RawData.FeeLimit = 1
+	if _, err := tt.EncodeJSON(true); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tt.Txid, txid) {
+		t.Fatal("EncodeJSON changed Txid")
+	}
+	other, _ := wallet.Generate()
+	if err := tt.Sign(other); !errors.Is(err, tx.ErrRawDataModified) {
+		t.Fatalf("Sign after tampering: %v", err)
+	}
+}
+
+// trc10Transfer is a TransferAssetContract in the visible JSON of the
+// java-tron HTTP API, asset_name is the UTF-8 token id
+const trc10Transfer = `{
+	"visible": true,
+	"txID": "f393f85256e9c06d38e8b8c811f6c2e26e9cfd074ddd3867bb0ce28bc775c21b",
+	"raw_data": {
+		"contract": [{
+			"parameter": {
+				"value": {
+					"amount": 1000000,
+					"asset_name": "1002000",
+					"owner_address": "TA25SJ2Uo4NQk2SozYKmZ4wQnpq5T7FNBZ",
+					"to_address": "TQhjuHnLWLYweT9L6EEVA9tBCpXXjFcpCb"
+				},
+				"type_url": "type.googleapis.com/protocol.TransferAssetContract"
+			},
+			"type": "TransferAssetContract"
+		}],
+		"ref_block_bytes": "a1b2",
+		"ref_block_hash": "0011223344556677",
+		"expiration": 1700000060000,
+		"timestamp": 1700000000000
+	},
+	"raw_data_hex": "0a02a1b22208001122334455667740e0a499ffbc315a75080212710a32747970652e676f6f676c65617069732e636f6d2f70726f746f636f6c2e5472616e736665724173736574436f6e7472616374123b0a0731303032303030121541008aeeda4d805471df9b2a5b0f38a0c3bcba786b1a1541a19d069d48d2e9392ec2bb41ecab0a72119d633b20c0843d7080d095ffbc31"
+}`
+
+func TestVisibleJSONNames(t *testing.T) {
+	tt, err := tx.DecodeJSON(nil, []byte(trc10Transfer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := tx.UnpackContracts(tt.Transaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := string(msgs[0].(*core.TransferAssetContract).AssetName); name != "1002000" {
+		t.Fatalf("asset_name %q", name)
+	}
+
+	// without raw_data_hex, raw_data alone gives the same transaction
+	var j map[string]any
+	if err := json.Unmarshal([]byte(trc10Transfer), &j); err != nil {
+		t.Fatal(err)
+	}
+	delete(j, "raw_data_hex")
+	data, _ := json.Marshal(j)
+	fromRaw, err := tx.DecodeJSON(nil, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fromRaw.Txid, tt.Txid) {
+		t.Fatalf("txid from raw_data %x, want %x", fromRaw.Txid, tt.Txid)
+	}
+
+	for visible, want := range map[bool]string{true: "1002000", false: "31303032303030"} {
+		data, err := tt.EncodeJSON(visible)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out struct {
+			RawData struct {
+				Contract []struct {
+					Parameter struct {
+						Value map[string]any `json:"value"`
+					} `json:"parameter"`
+				} `json:"contract"`
+			} `json:"raw_data"`
+		}
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Fatal(err)
+		}
+		if got := out.RawData.Contract[0].Parameter.Value["asset_name"]; got != want {
+			t.Fatalf("visible %v: asset_name %v, want %s", visible, got, want)
+		}
+		if _, err := tx.DecodeJSON(nil, data); err != nil {
+			t.Fatalf("visible %v: %v", visible, err)
+		}
+	}
+}
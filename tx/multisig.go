@@ -0,0 +1,341 @@
+package tx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/wallet"
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	OwnerPermissionID   int32 = 0
+	WitnessPermissionID int32 = 1
+	// ActivePermissionID is the id of the first active permission
+	ActivePermissionID int32 = 2
+)
+
+// DefaultActiveOperations is the operations bitmap of the active permission
+// of an account without explicit permissions. It mirrors
+// ACTIVE_DEFAULT_OPERATIONS of java-tron, the contract types 0 to 45 which
+// existed when multi-signature was introduced, from AccountCreateContract
+// to UpdateEnergyLimitContract. AccountPermissionUpdateContract and the
+// later types like ClearABIContract, UpdateBrokerageContract,
+// ShieldedTransferContract and the market contracts are not in it. The
+// chain parameter getActiveDefaultOperations may differ on a network
+var DefaultActiveOperations = append([]byte{0x7f, 0xff, 0x1f, 0xc0, 0x03, 0x3e}, make([]byte, 26)...)
+
+var (
+	ErrAlreadySigned      = fmt.Errorf("transaction already signed")
+	ErrThresholdNotMet    = fmt.Errorf("signature weight below the permission threshold")
+	ErrNotPermissionKey   = fmt.Errorf("signer is not a key of the permission")
+	ErrPermissionNotFound = fmt.Errorf("permission not found")
+	ErrRawDataMismatch    = fmt.Errorf("partial transaction has a different raw_data")
+)
+
+// SetPermissionID sets the permission_id of the contracts, it must be done
+// before the first signature
+func (tx *Transaction) SetPermissionID(id int32) error {
+	return tx.modify(func(raw *core.TransactionRaw) error {
+		for _, c := range raw.Contract {
+			c.PermissionId = id
+		}
+		return nil
+	})
+}
+
+// PermissionID returns the permission_id of the transaction
+func (tx *Transaction) PermissionID() int32 {
+	contracts := tx.GetRawData().GetContract()
+	if len(contracts) == 0 {
+		return 0
+	}
+	return contracts[0].PermissionId
+}
+
+// OwnerAddress returns the owner_address of the contract, the account whose
+// permissions authorize the transaction
+func (tx *Transaction) OwnerAddress() (address.Address, error) {
+	contracts := tx.GetRawData().GetContract()
+	if len(contracts) == 0 {
+		return nil, fmt.Errorf("transaction has no contract")
+	}
+	msg, err := contracts[0].GetParameter().UnmarshalNew()
+	if err != nil {
+		return nil, err
+	}
+	owner, ok := msg.(interface{ GetOwnerAddress() []byte })
+	if !ok {
+		return nil, fmt.Errorf("%s has no owner address", contracts[0].Type)
+	}
+	return owner.GetOwnerAddress(), nil
+}
+
+// GetPermission returns the permission id of an account, the owner is 0,
+// the witness 1 and the actives start at 2. An account without explicit
+// permissions has an owner permission and an active permission of its own
+// key, like java-tron gives it
+func GetPermission(acc *core.Account, id int32) (*core.Permission, error) {
+	var perm *core.Permission
+	switch {
+	case id == OwnerPermissionID:
+		perm = acc.GetOwnerPermission()
+		if perm == nil {
+			perm = &core.Permission{
+				Type:           core.Permission_Owner,
+				PermissionName: "owner",
+				Threshold:      1,
+				Keys:           []*core.Key{{Address: acc.GetAddress(), Weight: 1}},
+			}
+		}
+	case id == WitnessPermissionID:
+		perm = acc.GetWitnessPermission()
+	default:
+		for _, p := range acc.GetActivePermission() {
+			if p.Id == id {
+				perm = p
+				break
+			}
+		}
+		if perm == nil && id == ActivePermissionID && len(acc.GetActivePermission()) == 0 {
+			perm = &core.Permission{
+				Type:           core.Permission_Active,
+				Id:             ActivePermissionID,
+				PermissionName: "active",
+				Threshold:      1,
+				Operations:     append([]byte(nil), DefaultActiveOperations...),
+				Keys:           []*core.Key{{Address: acc.GetAddress(), Weight: 1}},
+			}
+		}
+	}
+	if perm == nil {
+		return nil, fmt.Errorf("%w: id %d", ErrPermissionNotFound, id)
+	}
+	return perm, nil
+}
+
+// SignWeight is the weight gathered by the signatures of a transaction
+type SignWeight struct {
+	Permission *core.Permission
+	Approved   []address.Address
+	Weight     int64
+}
+
+// Enough reports whether the weight reaches the threshold
+func (w *SignWeight) Enough() bool {
+	return w.Weight >= w.Permission.GetThreshold()
+}
+
+// SignWeight computes locally the weight of the signatures of tx in perm,
+// it fails if a signer is not a key of perm or signed twice like the node
+func (tx *Transaction) SignWeight(perm *core.Permission) (*SignWeight, error) {
+	signers, err := wallet.RecoverTransactionSigners(tx.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	w := &SignWeight{Permission: perm}
+	for _, signer := range signers {
+		weight, ok := keyWeight(perm, signer)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrNotPermissionKey, signer)
+		}
+		for _, approved := range w.Approved {
+			if bytes.Equal(approved, signer) {
+				return nil, fmt.Errorf("%s signed twice", signer)
+			}
+		}
+		w.Approved = append(w.Approved, signer)
+		w.Weight += weight
+	}
+	return w, nil
+}
+
+func keyWeight(perm *core.Permission, addr address.Address) (int64, bool) {
+	for _, key := range perm.GetKeys() {
+		if bytes.Equal(key.Address, addr) {
+			return key.Weight, true
+		}
+	}
+	return 0, false
+}
+
+// NodeSignWeight asks the node for the weight of the signatures of tx
+func (tx *Transaction) NodeSignWeight(ctx context.Context) (*api.TransactionSignWeight, error) {
+	w, err := tx.client.GetTransactionSignWeight(ctx, tx.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	switch w.GetResult().GetCode() {
+	case api.TransactionSignWeight_Result_ENOUGH_PERMISSION, api.TransactionSignWeight_Result_NOT_ENOUGH_PERMISSION:
+		return w, nil
+	}
+	return nil, fmt.Errorf("%s: %s", w.GetResult().GetCode(), w.GetResult().GetMessage())
+}
+
+// MultiSig gathers the signatures of a transaction for one permission of
+// the owner account until the threshold is met
+type MultiSig struct {
+	tx         *Transaction
+	permission *core.Permission
+}
+
+// NewMultiSig sets the permission of tx to permissionID of the owner
+// account, read from the node
+func NewMultiSig(ctx context.Context, tx *Transaction, permissionID int32) (*MultiSig, error) {
+	owner, err := tx.OwnerAddress()
+	if err != nil {
+		return nil, err
+	}
+	acc, err := tx.client.GetAccount(ctx, &core.Account{Address: owner})
+	if err != nil {
+		return nil, err
+	}
+	if len(acc.GetAddress()) == 0 {
+		return nil, fmt.Errorf("account %s not found", owner)
+	}
+	perm, err := GetPermission(acc, permissionID)
+	if err != nil {
+		return nil, err
+	}
+	return NewMultiSigWithPermission(tx, perm)
+}
+
+// NewMultiSigWithPermission is NewMultiSig with a known permission
+func NewMultiSigWithPermission(tx *Transaction, perm *core.Permission) (*MultiSig, error) {
+	if tx.PermissionID() != perm.Id {
+		if err := tx.SetPermissionID(perm.Id); err != nil {
+			return nil, err
+		}
+	} else {
+		if tx.signedHash == nil && len(tx.Signature) > 0 {
+			// keep the txid the signatures were made over as the reference
+			tx.signedHash = tx.Txid
+		}
+		if err := tx.updateHash(); err != nil {
+			return nil, err
+		}
+	}
+	return &MultiSig{tx: tx, permission: perm}, nil
+}
+
+// Transaction returns the transaction being signed
+func (m *MultiSig) Transaction() *Transaction {
+	return m.tx
+}
+
+// Permission returns the permission the signatures are checked against
+func (m *MultiSig) Permission() *core.Permission {
+	return m.permission
+}
+
+// Sign adds the signature of each signer, signers which already signed are
+// skipped and signers outside of the permission are refused
+func (m *MultiSig) Sign(signers ...Signer) error {
+	for _, signer := range signers {
+		if err := m.tx.checkSigned(); err != nil {
+			return err
+		}
+		sig, err := signer.SignTransaction(m.tx.Transaction)
+		if err != nil {
+			return err
+		}
+		if err := m.addSignature(sig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddSignature adds a signature made elsewhere
+func (m *MultiSig) AddSignature(sig []byte) error {
+	if err := m.tx.checkSigned(); err != nil {
+		return err
+	}
+	return m.addSignature(sig)
+}
+
+func (m *MultiSig) addSignature(sig []byte) error {
+	signer, err := wallet.RecoverAddress(m.tx.Txid, sig)
+	if err != nil {
+		return err
+	}
+	if _, ok := keyWeight(m.permission, signer); !ok {
+		return fmt.Errorf("%w: %s", ErrNotPermissionKey, signer)
+	}
+	signers, err := wallet.RecoverTransactionSigners(m.tx.Transaction)
+	if err != nil {
+		return err
+	}
+	for _, s := range signers {
+		if bytes.Equal(s, signer) {
+			return nil
+		}
+	}
+	m.tx.addSignature(sig)
+	return nil
+}
+
+// Merge adds the signatures of partially signed copies of the transaction
+func (m *MultiSig) Merge(partials ...*core.Transaction) error {
+	for _, p := range partials {
+		if !proto.Equal(p.GetRawData(), m.tx.GetRawData()) {
+			return ErrRawDataMismatch
+		}
+		for _, sig := range p.GetSignature() {
+			if err := m.AddSignature(sig); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MergeBytes is Merge with protobuf serialized transactions
+func (m *MultiSig) MergeBytes(partials ...[]byte) error {
+	for _, data := range partials {
+		p := new(core.Transaction)
+		if err := proto.Unmarshal(data, p); err != nil {
+			return err
+		}
+		if err := m.Merge(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Weight returns the weight gathered so far
+func (m *MultiSig) Weight() (*SignWeight, error) {
+	return m.tx.SignWeight(m.permission)
+}
+
+// Ready reports whether the threshold is met
+func (m *MultiSig) Ready() bool {
+	w, err := m.Weight()
+	return err == nil && w.Enough()
+}
+
+// Send broadcasts the transaction once the threshold is met, checked
+// locally then by the node
+func (m *MultiSig) Send(ctx context.Context) error {
+	w, err := m.Weight()
+	if err != nil {
+		return err
+	}
+	if !w.Enough() {
+		return fmt.Errorf("%w: %d of %d", ErrThresholdNotMet, w.Weight, m.permission.Threshold)
+	}
+	nw, err := m.tx.NodeSignWeight(ctx)
+	if err != nil {
+		return err
+	}
+	if nw.GetResult().GetCode() != api.TransactionSignWeight_Result_ENOUGH_PERMISSION {
+		return fmt.Errorf("%w: node reports %d of %d: %s", ErrThresholdNotMet,
+			nw.CurrentWeight, nw.GetPermission().GetThreshold(), nw.GetResult().GetMessage())
+	}
+	return m.tx.Send(ctx)
+}
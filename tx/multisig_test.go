@@ -0,0 +1,94 @@
+package tx_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"github.com/fullstackwang/tron-grpc/tx"
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+func allows(operations []byte, typ core.Transaction_Contract_ContractType) bool {
+	return operations[typ/8]&(1<<(typ%8)) != 0
+}
+
+func TestDefaultActivePermission(t *testing.T) {
+	owner, _ := wallet.Generate()
+	acc := &core.Account{Address: owner.Address()}
+	perm, err := tx.GetPermission(acc, tx.ActivePermissionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm.Type != core.Permission_Active || perm.Id != tx.ActivePermissionID || perm.Threshold != 1 {
+		t.Fatalf("default active permission %v", perm)
+	}
+	if len(perm.Keys) != 1 || !bytes.Equal(perm.Keys[0].Address, owner.Address()) {
+		t.Fatalf("default active keys %v", perm.Keys)
+	}
+	if !allows(perm.Operations, core.Transaction_Contract_TransferContract) ||
+		!allows(perm.Operations, core.Transaction_Contract_TriggerSmartContract) {
+		t.Fatal("default active permission does not allow transfers and calls")
+	}
+	if allows(perm.Operations, core.Transaction_Contract_AccountPermissionUpdateContract) {
+		t.Fatal("default active permission allows AccountPermissionUpdateContract")
+	}
+	// ACTIVE_DEFAULT_OPERATIONS of java-tron
+	want, _ := hex.DecodeString("7fff1fc0033e0000000000000000000000000000000000000000000000000000")
+	if !bytes.Equal(perm.Operations, want) {
+		t.Fatalf("default active operations %x, want %x", perm.Operations, want)
+	}
+
+	// explicit actives replace the default one
+	acc.ActivePermission = []*core.Permission{{Type: core.Permission_Active, Id: 3, Threshold: 1}}
+	if _, err := tx.GetPermission(acc, tx.ActivePermissionID); !errors.Is(err, tx.ErrPermissionNotFound) {
+		t.Fatalf("id 2 with explicit actives: got %v, want ErrPermissionNotFound", err)
+	}
+	if _, err := tx.GetPermission(acc, 4); !errors.Is(err, tx.ErrPermissionNotFound) {
+		t.Fatalf("id 4: got %v, want ErrPermissionNotFound", err)
+	}
+}
+
+func TestSendWithDefaultActivePermission(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	s.AutoMine = true
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Stop()
+
+	owner, _ := wallet.Generate()
+	to, _ := wallet.Generate()
+	s.SetBalance(owner.Address(), 1000)
+	head, err := c.GetNowBlock2(context.Background(), &api.EmptyMessage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt, err := tx.NewBuilder(&core.TransferContract{OwnerAddress: owner.Address(), ToAddress: to.Address(), Amount: 5}).
+		RefBlockExtention(head).
+		PermissionID(tx.ActivePermissionID).
+		Transaction(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := tx.NewMultiSig(context.Background(), tt, tx.ActivePermissionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ms.Sign(owner); err != nil {
+		t.Fatal(err)
+	}
+	if err := tt.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s.Balance(to.Address()) != 5 {
+		t.Fatalf("balance %d, want 5", s.Balance(to.Address()))
+	}
+}
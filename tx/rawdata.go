@@ -0,0 +1,99 @@
+package tx
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/core"
+)
+
+// MaxExpiration is how far after the head block the node accepts an
+// expiration
+const MaxExpiration = 24 * time.Hour
+
+var ErrRawDataModified = fmt.Errorf("raw_data modified after signing")
+
+// modify applies f to raw_data and recomputes Txid, it fails once the
+// transaction is signed since the signatures would no longer match
+func (tx *Transaction) modify(f func(raw *core.TransactionRaw) error) error {
+	if len(tx.Signature) > 0 {
+		return ErrAlreadySigned
+	}
+	if tx.GetRawData() == nil {
+		return fmt.Errorf("transaction has no raw_data")
+	}
+	if err := f(tx.RawData); err != nil {
+		return err
+	}
+	return tx.updateHash()
+}
+
+// SetExpiration sets the expiration, it must follow the timestamp of the
+// transaction by at most MaxExpiration. The node checks it against its head
+// block instead, so a transaction created long before its broadcast may
+// still be rejected
+func (tx *Transaction) SetExpiration(t time.Time) error {
+	return tx.modify(func(raw *core.TransactionRaw) error {
+		expiration := t.UnixMilli()
+		if raw.Timestamp > 0 {
+			if expiration <= raw.Timestamp {
+				return fmt.Errorf("expiration must be after the timestamp")
+			}
+			if expiration > raw.Timestamp+MaxExpiration.Milliseconds() {
+				return fmt.Errorf("expiration must be within %s of the timestamp", MaxExpiration)
+			}
+		}
+		raw.Expiration = expiration
+		return nil
+	})
+}
+
+// ExtendExpiration moves the expiration d later, e.g. for a multisig
+// transaction waiting for its signers
+func (tx *Transaction) ExtendExpiration(d time.Duration) error {
+	return tx.SetExpiration(time.UnixMilli(tx.GetRawData().GetExpiration()).Add(d))
+}
+
+// Expiration returns the expiration of the transaction
+func (tx *Transaction) Expiration() time.Time {
+	return time.UnixMilli(tx.GetRawData().GetExpiration())
+}
+
+// SetMemo sets raw_data.data, shown as the memo by the explorers. It
+// costs bandwidth like the rest of the transaction
+func (tx *Transaction) SetMemo(memo []byte) error {
+	return tx.modify(func(raw *core.TransactionRaw) error {
+		raw.Data = memo
+		return nil
+	})
+}
+
+// SetFeeLimit sets the maximum fee in sun of a smart contract call
+func (tx *Transaction) SetFeeLimit(sun int64) error {
+	return tx.modify(func(raw *core.TransactionRaw) error {
+		raw.FeeLimit = sun
+		return nil
+	})
+}
+
+// SetRefBlock pins the reference block, the transaction is only valid on
+// the chain holding it. The expiration is not changed
+func (tx *Transaction) SetRefBlock(header *core.BlockHeader) error {
+	id, err := BlockID(header)
+	if err != nil {
+		return err
+	}
+	return tx.SetRefBlockID(id)
+}
+
+// SetRefBlockID is SetRefBlock with a 32 bytes block id
+func (tx *Transaction) SetRefBlockID(id []byte) error {
+	if len(id) != 32 {
+		return fmt.Errorf("block id must be 32 bytes")
+	}
+	return tx.modify(func(raw *core.TransactionRaw) error {
+		raw.RefBlockBytes = append([]byte(nil), id[6:8]...)
+		raw.RefBlockHash = append([]byte(nil), id[8:16]...)
+		return nil
+	})
+}
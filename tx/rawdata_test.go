@@ -0,0 +1,98 @@
+package tx_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/tx"
+	"github.com/fullstackwang/tron-grpc/wallet"
+)
+
+func TestSignGuardWithoutTxid(t *testing.T) {
+	owner, built := newTransfer(t)
+	// wrapped like a transaction returned by the node, without txid
+	tt := tx.New(nil, built.Transaction)
+	cosigner, _ := wallet.Generate()
+	if err := tt.Sign(owner); err != nil {
+		t.Fatal(err)
+	}
+	signed := append([]byte(nil), tt.Txid...)
+
+	// re-hashing the tampered transaction must not bless the change
+	tt.RawData.Data = []byte("changed")
+	if _, err := tx.NewMultiSigWithPermission(tt, &core.Permission{Type: core.Permission_Owner, Threshold: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tt.Sign(cosigner); !errors.Is(err, tx.ErrRawDataModified) {
+		t.Fatalf("sign after tampering: got %v, want ErrRawDataModified", err)
+	}
+	if string(tt.Txid) != string(signed) {
+		t.Fatalf("txid %x, want the signed %x", tt.Txid, signed)
+	}
+	if len(tt.Signature) != 1 {
+		t.Fatalf("%d signatures, want 1", len(tt.Signature))
+	}
+}
+
+func TestMultiSigGuard(t *testing.T) {
+	owner, tt := newTransfer(t)
+	cosigner, _ := wallet.Generate()
+	perm := &core.Permission{
+		Type:      core.Permission_Owner,
+		Threshold: 2,
+		Keys:      []*core.Key{{Address: owner.Address(), Weight: 1}, {Address: cosigner.Address(), Weight: 1}},
+	}
+	m, err := tx.NewMultiSigWithPermission(tt, perm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Sign(owner); err != nil {
+		t.Fatal(err)
+	}
+	signed := append([]byte(nil), tt.Txid...)
+
+	tt.RawData.Data = []byte("changed")
+	if err := m.Sign(cosigner); !errors.Is(err, tx.ErrRawDataModified) {
+		t.Fatalf("sign after tampering: got %v, want ErrRawDataModified", err)
+	}
+	// a signature made over the tampered raw_data is refused too
+	sig, err := cosigner.SignTransaction(tt.Transaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.AddSignature(sig); !errors.Is(err, tx.ErrRawDataModified) {
+		t.Fatalf("add signature after tampering: got %v, want ErrRawDataModified", err)
+	}
+	if string(tt.Txid) != string(signed) || len(tt.Signature) != 1 {
+		t.Fatalf("txid %x with %d signatures, want the signed %x with 1", tt.Txid, len(tt.Signature), signed)
+	}
+	if m.Ready() {
+		t.Fatal("tampered transaction ready")
+	}
+}
+
+func TestSetExpiration(t *testing.T) {
+	owner, tt := newTransfer(t)
+	timestamp := time.UnixMilli(tt.RawData.Timestamp)
+	if err := tt.SetExpiration(timestamp); err == nil {
+		t.Fatal("expiration at the timestamp accepted")
+	}
+	if err := tt.SetExpiration(timestamp.Add(tx.MaxExpiration + time.Second)); err == nil {
+		t.Fatal("expiration past MaxExpiration accepted")
+	}
+	before := append([]byte(nil), tt.Txid...)
+	if err := tt.SetExpiration(timestamp.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if string(tt.Txid) == string(before) {
+		t.Fatal("txid not updated")
+	}
+	if err := tt.Sign(owner); err != nil {
+		t.Fatal(err)
+	}
+	if err := tt.ExtendExpiration(time.Minute); !errors.Is(err, tx.ErrAlreadySigned) {
+		t.Fatalf("extend a signed transaction: got %v, want ErrAlreadySigned", err)
+	}
+}
@@ -0,0 +1,168 @@
+package tx
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/abi"
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/core"
+)
+
+// revertABI declares the errors of Solidity, the revert data is encoded
+// like a call of them
+var revertABI, _ = abi.Parse([]byte(`[
+	{"type": "function", "name": "Error", "inputs": [{"name": "reason", "type": "string"}]},
+	{"type": "function", "name": "Panic", "inputs": [{"name": "code", "type": "uint256"}]}
+]`))
+
+// panicReasons are the Panic(uint256) codes of Solidity
+var panicReasons = map[int64]string{
+	0x00: "generic panic",
+	0x01: "assert failed",
+	0x11: "arithmetic overflow or underflow",
+	0x12: "division or modulo by zero",
+	0x21: "invalid enum value",
+	0x22: "invalid storage byte array encoding",
+	0x31: "pop on empty array",
+	0x32: "array index out of bounds",
+	0x41: "out of memory",
+	0x51: "call to a zero function",
+}
+
+// RevertError is a contract call reverted, with the reason of the revert
+// data when it is an Error(string) or a Panic(uint256)
+type RevertError struct {
+	// Reason is the message of require and revert
+	Reason string
+	// PanicCode is set for a Panic, e.g. 0x11 on overflow
+	PanicCode *big.Int
+	// Data is the raw revert data, e.g. a custom error
+	Data []byte
+}
+
+// DecodeRevert decodes the revert data of a contract call
+func DecodeRevert(data []byte) *RevertError {
+	e := &RevertError{Data: data}
+	if len(data) < 4 {
+		return e
+	}
+	m := revertABI.MethodBySig(data[:4])
+	if m == nil {
+		return e
+	}
+	args, err := m.DecodeInput(data)
+	if err != nil || len(args) != 1 {
+		return e
+	}
+	switch v := args[0].(type) {
+	case string:
+		e.Reason = v
+	case *big.Int:
+		e.PanicCode = v
+	}
+	return e
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.PanicCode != nil:
+		reason, ok := panicReasons[e.PanicCode.Int64()]
+		if !ok || !e.PanicCode.IsInt64() {
+			reason = "unknown panic"
+		}
+		return fmt.Sprintf("execution reverted: panic 0x%x (%s)", e.PanicCode, reason)
+	case e.Reason != "":
+		return "execution reverted: " + e.Reason
+	case len(e.Data) > 0:
+		return fmt.Sprintf("execution reverted: 0x%x", e.Data)
+	}
+	return "execution reverted"
+}
+
+// Receipt is the outcome of an included transaction, the fees are in sun
+type Receipt struct {
+	Txid            []byte
+	BlockNumber     int64
+	BlockTime       time.Time
+	Result          core.Transaction_ResultContractResult
+	Message         string
+	ContractAddress address.Address
+	ContractResult  [][]byte
+	Logs            []*core.TransactionInfo_Log
+
+	// EnergyUsage is the energy of the caller's stake, OriginEnergyUsage
+	// the one of the contract owner and EnergyUsageTotal all the energy
+	EnergyUsage       int64
+	OriginEnergyUsage int64
+	EnergyUsageTotal  int64
+	// EnergyFee is the TRX burnt for the energy not covered by stake
+	EnergyFee int64
+	// NetUsage is the bandwidth of the stake, or 0 when NetFee was burnt
+	NetUsage int64
+	NetFee   int64
+	// Fee is all the TRX burnt by the transaction
+	Fee int64
+
+	info *core.TransactionInfo
+}
+
+// NewReceipt returns the receipt of info
+func NewReceipt(info *core.TransactionInfo) *Receipt {
+	rr := info.GetReceipt()
+	r := &Receipt{
+		Txid:              info.Id,
+		BlockNumber:       info.BlockNumber,
+		BlockTime:         time.UnixMilli(info.BlockTimeStamp),
+		Result:            rr.GetResult(),
+		Message:           string(info.ResMessage),
+		ContractResult:    info.ContractResult,
+		Logs:              info.Log,
+		EnergyUsage:       rr.GetEnergyUsage(),
+		OriginEnergyUsage: rr.GetOriginEnergyUsage(),
+		EnergyUsageTotal:  rr.GetEnergyUsageTotal(),
+		EnergyFee:         rr.GetEnergyFee(),
+		NetUsage:          rr.GetNetUsage(),
+		NetFee:            rr.GetNetFee(),
+		Fee:               info.Fee,
+		info:              info,
+	}
+	if len(info.ContractAddress) > 0 {
+		r.ContractAddress = info.ContractAddress
+	}
+	return r
+}
+
+// Receipt returns the receipt of a confirmed transaction
+func (tx *Transaction) Receipt() (*Receipt, error) {
+	if !tx.Confirmed || tx.Info == nil {
+		return nil, fmt.Errorf("tx not confirmed")
+	}
+	return NewReceipt(tx.Info), nil
+}
+
+// Success reports whether the transaction executed
+func (r *Receipt) Success() bool {
+	return r.Err() == nil
+}
+
+// Err returns the *FailedError of a failed transaction, its Revert holds
+// the decoded revert reason
+func (r *Receipt) Err() error {
+	return failure(r.info)
+}
+
+// Revert returns the revert of a reverted contract call, else nil
+func (r *Receipt) Revert() *RevertError {
+	if r.Result != core.Transaction_Result_REVERT {
+		return nil
+	}
+	return DecodeRevert(bytes.Join(r.ContractResult, nil))
+}
+
+// TotalFee is the fee of the receipt as an Amount
+func (r *Receipt) TotalFee() Amount {
+	return NewAmount(r.Fee)
+}
@@ -0,0 +1,163 @@
+package tx_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/fullstackwang/tron-grpc/abi"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/tx"
+)
+
+func revertData(t *testing.T, selector string, words ...string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(selector + strings.Join(words, ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func word(v string) string {
+	return strings.Repeat("0", 64-len(v)) + v
+}
+
+func TestDecodeRevert(t *testing.T) {
+	custom := hex.EncodeToString(abi.GetKeccak256Hash([]byte("InsufficientBalance(uint256,uint256)"))[:4])
+	for _, tc := range []struct {
+		name   string
+		data   []byte
+		reason string
+		panic  int64
+		err    string
+	}{
+		{
+			name:   "Error(string)",
+			data:   revertData(t, "08c379a0", word("20"), word("a"), "4e6f7420656e6f75676800000000000000000000000000000000000000000000"),
+			reason: "Not enough",
+			err:    "execution reverted: Not enough",
+		},
+		{
+			name:  "Panic overflow",
+			data:  revertData(t, "4e487b71", word("11")),
+			panic: 0x11,
+			err:   "execution reverted: panic 0x11 (arithmetic overflow or underflow)",
+		},
+		{
+			name:  "Panic division by zero",
+			data:  revertData(t, "4e487b71", word("12")),
+			panic: 0x12,
+			err:   "execution reverted: panic 0x12 (division or modulo by zero)",
+		},
+		{
+			name:  "Panic unknown code",
+			data:  revertData(t, "4e487b71", word("99")),
+			panic: 0x99,
+			err:   "execution reverted: panic 0x99 (unknown panic)",
+		},
+		{
+			name: "custom error",
+			data: revertData(t, custom, word("1"), word("2")),
+			err:  "execution reverted: 0x" + custom + word("1") + word("2"),
+		},
+		{
+			name: "empty",
+			err:  "execution reverted",
+		},
+		{
+			name: "truncated Error(string)",
+			data: revertData(t, "08c379a0", word("20"), word("a")),
+			err:  "execution reverted: 0x08c379a0" + word("20") + word("a"),
+		},
+		{
+			name: "shorter than a selector",
+			data: revertData(t, "08c379"),
+			err:  "execution reverted: 0x08c379",
+		},
+	} {
+		e := tx.DecodeRevert(tc.data)
+		if e.Reason != tc.reason {
+			t.Errorf("%s: reason %q, want %q", tc.name, e.Reason, tc.reason)
+		}
+		if tc.panic == 0 && e.PanicCode != nil || tc.panic != 0 && (e.PanicCode == nil || e.PanicCode.Int64() != tc.panic) {
+			t.Errorf("%s: panic code %v, want %d", tc.name, e.PanicCode, tc.panic)
+		}
+		if !bytes.Equal(e.Data, tc.data) {
+			t.Errorf("%s: data %x", tc.name, e.Data)
+		}
+		if e.Error() != tc.err {
+			t.Errorf("%s: error %q, want %q", tc.name, e.Error(), tc.err)
+		}
+	}
+}
+
+func TestReceipt(t *testing.T) {
+	reverted := &core.TransactionInfo{
+		Id:             []byte{1},
+		Result:         core.TransactionInfo_FAILED,
+		ContractResult: [][]byte{revertData(t, "4e487b71", word("11"))},
+		Receipt:        &core.ResourceReceipt{Result: core.Transaction_Result_REVERT},
+	}
+	outOfEnergy := &core.TransactionInfo{
+		Id:         []byte{2},
+		Fee:        27_255_900,
+		Result:     core.TransactionInfo_FAILED,
+		ResMessage: []byte("Not enough energy for 'SSTORE' operation executing"),
+		Receipt: &core.ResourceReceipt{
+			Result:           core.Transaction_Result_OUT_OF_ENERGY,
+			EnergyUsageTotal: 64_895,
+			EnergyFee:        27_255_900,
+			NetUsage:         345,
+		},
+	}
+	succeeded := &core.TransactionInfo{
+		Id:      []byte{3},
+		Fee:     345_000,
+		Receipt: &core.ResourceReceipt{Result: core.Transaction_Result_SUCCESS, NetFee: 345_000},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		info        *core.TransactionInfo
+		success     bool
+		outOfEnergy bool
+		revert      bool
+	}{
+		{"success", succeeded, true, false, false},
+		{"revert", reverted, false, false, true},
+		{"out of energy", outOfEnergy, false, true, false},
+	} {
+		r := tx.NewReceipt(tc.info)
+		if r.Success() != tc.success {
+			t.Errorf("%s: success %v", tc.name, r.Success())
+		}
+		err := r.Err()
+		if tc.success != (err == nil) || !tc.success && !errors.Is(err, tx.ErrFailed) {
+			t.Errorf("%s: error %v", tc.name, err)
+		}
+		if errors.Is(err, tx.ErrOutOfEnergy) != tc.outOfEnergy {
+			t.Errorf("%s: out of energy %v", tc.name, err)
+		}
+		if (r.Revert() != nil) != tc.revert {
+			t.Errorf("%s: revert %v", tc.name, r.Revert())
+		}
+		if r.Fee != tc.info.Fee || r.TotalFee() != tx.NewAmount(tc.info.Fee) {
+			t.Errorf("%s: fee %d %v", tc.name, r.Fee, r.TotalFee())
+		}
+	}
+
+	r := tx.NewReceipt(outOfEnergy)
+	if r.EnergyUsageTotal != 64_895 || r.EnergyFee != 27_255_900 || r.NetUsage != 345 || r.Result != core.Transaction_Result_OUT_OF_ENERGY {
+		t.Fatalf("out of energy receipt %+v", r)
+	}
+	var failed *tx.FailedError
+	if !errors.As(r.Err(), &failed) || failed.Message != string(outOfEnergy.ResMessage) || failed.Revert != nil {
+		t.Fatalf("out of energy error %v", r.Err())
+	}
+	if !errors.As(tx.NewReceipt(reverted).Err(), &failed) || failed.Revert == nil || failed.Revert.PanicCode.Int64() != 0x11 {
+		t.Fatalf("revert error %v", tx.NewReceipt(reverted).Err())
+	}
+}
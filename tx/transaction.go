@@ -1,6 +1,7 @@
 package tx
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
@@ -8,7 +9,6 @@ import (
 	"github.com/fullstackwang/tron-grpc/api"
 	"github.com/fullstackwang/tron-grpc/core"
 	"github.com/golang/protobuf/proto"
-	"time"
 )
 
 type Signer interface {
@@ -25,30 +25,57 @@ type Transaction struct {
 	Txid          []byte
 	Info          *core.TransactionInfo
 	resultDecoder ResultDecoder
+	// signedHash is the hash of raw_data covered by the signatures, recorded
+	// by the first Sign
+	signedHash []byte
 }
 
 func (tx *Transaction) Sign(signer Signer) error {
-	err := tx.updateHash()
-	if err != nil {
+	if err := tx.checkSigned(); err != nil {
 		return err
 	}
 	sig, err := signer.SignTransaction(tx.Transaction)
 	if err != nil {
 		return err
 	}
-	tx.Signature = append(tx.Signature, sig)
+	tx.addSignature(sig)
+	return nil
+}
+
+// checkSigned updates the txid and fails when raw_data changed since the
+// first signature, which fixes raw_data
+func (tx *Transaction) checkSigned() error {
+	signed := tx.signedHash
+	if signed == nil && len(tx.Signature) > 0 {
+		// signed elsewhere, the txid known before this check is the reference
+		signed = tx.Txid
+	}
+	if err := tx.updateHash(); err != nil {
+		return err
+	}
+	if len(tx.Signature) > 0 && signed != nil && !bytes.Equal(signed, tx.Txid) {
+		tx.Txid = signed
+		return ErrRawDataModified
+	}
 	return nil
 }
 
+// addSignature appends sig, made over the current txid
+func (tx *Transaction) addSignature(sig []byte) {
+	tx.Signature = append(tx.Signature, sig)
+	if tx.signedHash == nil {
+		tx.signedHash = tx.Txid
+	}
+}
+
+// Send broadcasts the transaction once, a rejection is a *BroadcastError.
+// Broadcaster also retries on other nodes
 func (tx *Transaction) Send(ctx context.Context) error {
 	ret, err := tx.client.BroadcastTransaction(ctx, tx.Transaction)
 	if err != nil {
 		return err
 	}
-	if ret.Code > 0 {
-		return fmt.Errorf(string(ret.Message))
-	}
-	return nil
+	return broadcastError(tx.Txid, ret)
 }
 
 func (tx *Transaction) SignAndSend(ctx context.Context, signer Signer) error {
@@ -83,32 +110,12 @@ func (tx *Transaction) CheckConfirmation() error {
 	return nil
 }
 
-func (tx *Transaction) WaitConfirmation() error {
-	in := api.BytesMessage{Value: tx.Txid}
-	timeout := time.Now().Add(10 * time.Second)
-	for {
-		info, err := tx.client.GetTransactionInfoById(context.Background(), &in)
-		if err != nil {
-			return err
-		}
-		if info != nil && info.Id != nil {
-			tx.Info = info
-			tx.Confirmed = true
-			return nil
-		}
-		if time.Now().After(timeout) {
-			return fmt.Errorf("timeout")
-		}
-		time.Sleep(2 * time.Second)
-	}
-}
-
 func (tx *Transaction) GetResult() ([]any, error) {
 	if !tx.Confirmed {
 		return nil, fmt.Errorf("tx not confirmed")
 	}
-	if tx.Info.Result > 0 {
-		return nil, fmt.Errorf(string(tx.Info.ResMessage))
+	if err := failure(tx.Info); err != nil {
+		return nil, err
 	}
 	if tx.resultDecoder == nil {
 		return nil, fmt.Errorf("no result decoder")
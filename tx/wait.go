@@ -0,0 +1,248 @@
+package tx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/core"
+)
+
+// Finality is the state a transaction is waited for
+type Finality int
+
+const (
+	// Included waits for the transaction to be in a block of the full node
+	Included Finality = iota
+	// Solidified waits for its block to be solidified, it can no longer be
+	// reverted by a fork
+	Solidified
+)
+
+const (
+	defaultPollInterval = 3 * time.Second
+	defaultWaitTimeout  = time.Minute
+)
+
+// SolidityProvider is a wallet client which also reaches a solidity node,
+// like *client.Client
+type SolidityProvider interface {
+	WalletSolidity() api.WalletSolidityClient
+}
+
+var (
+	ErrExpired     = fmt.Errorf("transaction expired")
+	ErrWaitTimeout = fmt.Errorf("timeout waiting for transaction")
+	ErrFailed      = fmt.Errorf("transaction failed")
+	ErrOutOfEnergy = fmt.Errorf("out of energy")
+)
+
+// FailedError is returned for a transaction included with a failed result.
+// It matches ErrFailed, ErrOutOfEnergy when the energy ran out, and wraps
+// the *RevertError of a reverted call
+type FailedError struct {
+	Txid    []byte
+	Result  core.Transaction_ResultContractResult
+	Message string
+	Revert  *RevertError
+	Info    *core.TransactionInfo
+}
+
+func (e *FailedError) Error() string {
+	switch {
+	case e.Revert != nil:
+		return fmt.Sprintf("transaction %x failed: %s", e.Txid, e.Revert)
+	case e.Message == "":
+		return fmt.Sprintf("transaction %x failed: %s", e.Txid, e.Result)
+	}
+	return fmt.Sprintf("transaction %x failed: %s: %s", e.Txid, e.Result, e.Message)
+}
+
+func (e *FailedError) Is(target error) bool {
+	return target == ErrFailed ||
+		target == ErrOutOfEnergy && e.Result == core.Transaction_Result_OUT_OF_ENERGY
+}
+
+func (e *FailedError) Unwrap() error {
+	if e.Revert == nil {
+		return nil
+	}
+	return e.Revert
+}
+
+// WaitOptions sets how WaitConfirmation polls, nil is the default
+type WaitOptions struct {
+	// PollInterval is the time between two checks, 3s by default
+	PollInterval time.Duration
+	// Timeout bounds the wait, 1 minute by default. The wait also stops
+	// with the context, or once the transaction expired without inclusion
+	Timeout time.Duration
+	// Finality is the state waited for, Included by default
+	Finality Finality
+	// Solidity is the service checking solidification, by default the
+	// one of the client of the transaction when it is a SolidityProvider
+	Solidity api.WalletSolidityClient
+}
+
+// failure returns the FailedError of a failed info, nil on success
+func failure(info *core.TransactionInfo) error {
+	result := info.GetReceipt().GetResult()
+	if info.Result != core.TransactionInfo_FAILED &&
+		(result == core.Transaction_Result_DEFAULT || result == core.Transaction_Result_SUCCESS) {
+		return nil
+	}
+	e := &FailedError{
+		Txid:    info.Id,
+		Result:  result,
+		Message: string(info.ResMessage),
+		Info:    info,
+	}
+	if result == core.Transaction_Result_REVERT {
+		e.Revert = DecodeRevert(bytes.Join(info.ContractResult, nil))
+	}
+	return e
+}
+
+// WaitConfirmation waits up to 10s for the transaction to be included, a
+// failed execution is left to GetResult
+func (tx *Transaction) WaitConfirmation() error {
+	err := tx.WaitConfirmationContext(context.Background(), &WaitOptions{
+		PollInterval: 2 * time.Second,
+		Timeout:      10 * time.Second,
+	})
+	if errors.Is(err, ErrFailed) {
+		return nil
+	}
+	return err
+}
+
+// WaitConfirmationContext waits until the transaction reaches
+// opts.Finality. Info is set once it is included, and a failed execution
+// returns a *FailedError. ErrExpired means it will never be included and
+// may be rebuilt
+func (tx *Transaction) WaitConfirmationContext(ctx context.Context, opts *WaitOptions) error {
+	if opts == nil {
+		opts = &WaitOptions{}
+	}
+	interval, timeout := opts.PollInterval, opts.Timeout
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	if timeout <= 0 {
+		timeout = defaultWaitTimeout
+	}
+	solidity := opts.Solidity
+	if solidity == nil && opts.Finality == Solidified {
+		p, ok := tx.client.(SolidityProvider)
+		if !ok {
+			return fmt.Errorf("no WalletSolidity client to check solidification, set WaitOptions.Solidity")
+		}
+		solidity = p.WalletSolidity()
+	}
+	if len(tx.Txid) == 0 {
+		if err := tx.updateHash(); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		done, err := tx.checkFinality(ctx, opts.Finality, solidity)
+		if err != nil && ctx.Err() != nil {
+			// the rpc was cut by the end of the wait
+			return tx.waitError(ctx)
+		}
+		if done || err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return tx.waitError(ctx)
+		case <-ticker.C:
+		}
+	}
+}
+
+func (tx *Transaction) waitError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w %x", ErrWaitTimeout, tx.Txid)
+	}
+	return ctx.Err()
+}
+
+func (tx *Transaction) checkFinality(ctx context.Context, finality Finality, solidity api.WalletSolidityClient) (bool, error) {
+	in := &api.BytesMessage{Value: tx.Txid}
+	if tx.Info == nil {
+		info, err := tx.client.GetTransactionInfoById(ctx, in)
+		if err != nil {
+			return false, err
+		}
+		if len(info.GetId()) == 0 {
+			expired, err := tx.expired(ctx)
+			if err != nil || !expired {
+				return false, err
+			}
+			// it may have landed in the head block since the lookup
+			info, err = tx.client.GetTransactionInfoById(ctx, in)
+			if err != nil {
+				return false, err
+			}
+			if len(info.GetId()) == 0 {
+				expiration := tx.GetRawData().GetExpiration()
+				return false, fmt.Errorf("%w: %x at %s", ErrExpired, tx.Txid, time.UnixMilli(expiration).UTC().Format(time.RFC3339))
+			}
+		}
+		tx.Info = info
+	}
+	if finality == Solidified {
+		block, err := solidity.GetNowBlock2(ctx, &api.EmptyMessage{})
+		if err != nil {
+			return false, err
+		}
+		if block.GetBlockHeader().GetRawData().GetNumber() < tx.Info.BlockNumber {
+			return false, nil
+		}
+		// the solidified info, in case a fork moved the transaction
+		info, err := solidity.GetTransactionInfoById(ctx, in)
+		if err != nil {
+			return false, err
+		}
+		if len(info.GetId()) == 0 {
+			tx.Info = nil
+			return false, nil
+		}
+		tx.Info = info
+	}
+	tx.Confirmed = true
+	return true, failure(tx.Info)
+}
+
+// expired reports whether the transaction can no longer be included. The
+// node checks the expiration against the parent of the block being built,
+// so a block past the expiration may still include it. No later block can
+// once the parent of the head is past the expiration
+func (tx *Transaction) expired(ctx context.Context) (bool, error) {
+	expiration := tx.GetRawData().GetExpiration()
+	if expiration == 0 {
+		return false, nil
+	}
+	head, err := tx.client.GetNowBlock2(ctx, &api.EmptyMessage{})
+	if err != nil {
+		return false, err
+	}
+	raw := head.GetBlockHeader().GetRawData()
+	if raw.GetTimestamp() <= expiration || raw.GetNumber() == 0 {
+		return false, nil
+	}
+	parent, err := tx.client.GetBlockByNum2(ctx, &api.NumberMessage{Num: raw.GetNumber() - 1})
+	if err != nil {
+		return false, err
+	}
+	return parent.GetBlockHeader().GetRawData().GetTimestamp() > expiration, nil
+}
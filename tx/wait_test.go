@@ -0,0 +1,187 @@
+package tx_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/api"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/fullstackwang/tron-grpc/testutil"
+	"github.com/fullstackwang/tron-grpc/tx"
+	"github.com/fullstackwang/tron-grpc/wallet"
+	"google.golang.org/grpc"
+)
+
+func sendTrigger(t *testing.T, s *testutil.Server, handler testutil.ContractHandler) *tx.Transaction {
+	t.Helper()
+	c, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Stop)
+	owner, _ := wallet.Generate()
+	token, _ := wallet.Generate()
+	s.SetBalance(owner.Address(), 1000)
+	s.SetContract(token.Address(), handler)
+	ext, err := c.TriggerContract(context.Background(), &core.TriggerSmartContract{
+		OwnerAddress:    owner.Address(),
+		ContractAddress: token.Address(),
+		Data:            []byte{1, 2, 3, 4},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt := tx.New(c, ext.Transaction)
+	if err := tt.SignAndSend(context.Background(), owner); err != nil {
+		t.Fatal(err)
+	}
+	return tt
+}
+
+func succeed(*core.TriggerSmartContract) ([]byte, []*core.TransactionInfo_Log, error) {
+	return nil, nil, nil
+}
+
+func TestWaitIncluded(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	s.AutoMine = true
+	tt := sendTrigger(t, s, succeed)
+	if err := tt.WaitConfirmationContext(context.Background(), &tx.WaitOptions{PollInterval: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if !tt.Confirmed || tt.Info == nil {
+		t.Fatal("not confirmed")
+	}
+}
+
+func TestWaitSolidified(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	s.AutoMine = true
+	s.SetSolidityLag(2)
+	tt := sendTrigger(t, s, succeed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	opts := &tx.WaitOptions{PollInterval: time.Millisecond, Finality: tx.Solidified}
+	if err := tt.WaitConfirmationContext(ctx, opts); !errors.Is(err, tx.ErrWaitTimeout) {
+		t.Fatalf("solidified before the lag: %v", err)
+	}
+	s.ProduceBlock()
+	s.ProduceBlock()
+	if err := tt.WaitConfirmationContext(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitExplicitSolidity(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	s.AutoMine = true
+	tt := sendTrigger(t, s, succeed)
+	c, _ := s.Client()
+	defer c.Stop()
+	// a client which is not a SolidityProvider needs WaitOptions.Solidity
+	bare := tx.New(struct{ api.WalletClient }{c}, tt.Transaction)
+	opts := &tx.WaitOptions{PollInterval: time.Millisecond, Finality: tx.Solidified}
+	if err := bare.WaitConfirmationContext(context.Background(), opts); err == nil {
+		t.Fatal("waited for solidification without a solidity client")
+	}
+	opts.Solidity = c.Solidity()
+	if err := bare.WaitConfirmationContext(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWaitFailed(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	s.AutoMine = true
+	tt := sendTrigger(t, s, func(*core.TriggerSmartContract) ([]byte, []*core.TransactionInfo_Log, error) {
+		return nil, nil, &testutil.RevertError{}
+	})
+	err := tt.WaitConfirmationContext(context.Background(), &tx.WaitOptions{PollInterval: time.Millisecond})
+	var failed *tx.FailedError
+	if !errors.As(err, &failed) || failed.Result != core.Transaction_Result_REVERT {
+		t.Fatalf("got %v", err)
+	}
+	// WaitConfirmation only waits for the inclusion, GetResult reports the failure
+	if err := tt.WaitConfirmation(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tt.GetResult(); !errors.Is(err, tx.ErrFailed) {
+		t.Fatalf("GetResult: %v", err)
+	}
+}
+
+// blockInterval is the block interval of testutil in milliseconds
+const blockInterval = 3000
+
+// missFirst hides the transaction info from the first lookup and produces
+// the block including it meanwhile
+type missFirst struct {
+	api.WalletClient
+	s      *testutil.Server
+	missed bool
+}
+
+func (c *missFirst) GetTransactionInfoById(ctx context.Context, in *api.BytesMessage, opts ...grpc.CallOption) (*core.TransactionInfo, error) {
+	if !c.missed {
+		c.missed = true
+		c.s.ProduceBlock()
+		return &core.TransactionInfo{}, nil
+	}
+	return c.WalletClient.GetTransactionInfoById(ctx, in, opts...)
+}
+
+// the node checks the expiration against the parent block, so the first
+// block past the expiration may still include the transaction
+func TestWaitIncludedPastExpiration(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	c, _ := s.Client()
+	defer c.Stop()
+	head := s.ProduceBlock()
+	// signedTransfer produces the parent of the including block
+	expiration := time.UnixMilli(head.GetBlockHeader().GetRawData().GetTimestamp() + blockInterval + 1)
+	sent := signedTransfer(t, s, c, expiration)
+	if err := sent.Send(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tt := tx.New(&missFirst{WalletClient: c, s: s}, sent.Transaction)
+	if err := tt.WaitConfirmationContext(context.Background(), &tx.WaitOptions{PollInterval: time.Millisecond}); err != nil {
+		t.Fatal(err)
+	}
+	if !tt.Confirmed {
+		t.Fatal("not confirmed")
+	}
+	if ts := tt.Info.GetBlockTimeStamp(); ts <= expiration.UnixMilli() {
+		t.Fatalf("included at %d, not past the expiration %d", ts, expiration.UnixMilli())
+	}
+}
+
+func TestWaitExpired(t *testing.T) {
+	s := testutil.NewServer()
+	defer s.Stop()
+	c, _ := s.Client()
+	defer c.Stop()
+	head := s.ProduceBlock()
+	tt := signedTransfer(t, s, c, time.UnixMilli(head.GetBlockHeader().GetRawData().GetTimestamp()+blockInterval+1))
+	opts := &tx.WaitOptions{PollInterval: time.Millisecond}
+
+	// the head is past the expiration, its parent is not
+	s.ProduceBlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := tt.WaitConfirmationContext(ctx, opts); !errors.Is(err, tx.ErrWaitTimeout) {
+		t.Fatalf("one block past the expiration: %v", err)
+	}
+	s.ProduceBlock()
+	if err := tt.WaitConfirmationContext(context.Background(), opts); !errors.Is(err, tx.ErrExpired) {
+		t.Fatalf("got %v", err)
+	}
+}
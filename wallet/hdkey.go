@@ -0,0 +1,360 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/dustinxie/ecc"
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/shengdoushi/base58"
+	"golang.org/x/crypto/ripemd160"
+)
+
+const (
+	// HardenedOffset is added to a child index for hardened derivation
+	HardenedOffset uint32 = 0x80000000
+	// CoinType is the SLIP-44 coin type of TRON
+	CoinType uint32 = 195
+
+	xprvVersion uint32 = 0x0488ade4
+	xpubVersion uint32 = 0x0488b21e
+)
+
+var (
+	ErrHardenedFromPublic = fmt.Errorf("cannot derive a hardened child from a public key")
+	ErrInvalidChild       = fmt.Errorf("invalid child key, use the next index")
+	ErrInvalidHDKey       = fmt.Errorf("invalid extended key")
+)
+
+// HDKey is a BIP-32 extended key. A private HDKey derives wallets, a public
+// one (xpub) only derives watch-only addresses
+type HDKey struct {
+	depth     uint8
+	parentFP  [4]byte
+	childNum  uint32
+	chainCode []byte
+	privKey   *ecdsa.PrivateKey
+	pubKey    *ecdsa.PublicKey
+}
+
+// NewMasterKey returns the BIP-32 master key of a seed
+func NewMasterKey(seed []byte) (*HDKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, fmt.Errorf("seed must be 16 to 64 bytes")
+	}
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	k := new(big.Int).SetBytes(sum[:32])
+	if k.Sign() == 0 || k.Cmp(ecc.P256k1().Params().N) >= 0 {
+		return nil, ErrInvalidHDKey
+	}
+	p := privKeyFromBytes(sum[:32])
+	return &HDKey{chainCode: sum[32:], privKey: p, pubKey: &p.PublicKey}, nil
+}
+
+// NewMasterKeyFromMnemonic returns the master key of a BIP-39 mnemonic
+func NewMasterKeyFromMnemonic(mnemonic, passphrase string) (*HDKey, error) {
+	seed, err := MnemonicToSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return NewMasterKey(seed)
+}
+
+// FromMnemonic returns the wallet at m/44'/195'/account'/0/index
+func FromMnemonic(mnemonic, passphrase string, account, index uint32) (*Wallet, error) {
+	master, err := NewMasterKeyFromMnemonic(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	key, err := master.Derive(DerivationPath(account, index))
+	if err != nil {
+		return nil, err
+	}
+	return key.Wallet()
+}
+
+// DerivationPath returns the BIP-44 path m/44'/195'/account'/0/index
+func DerivationPath(account, index uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'/0/%d", CoinType, account, index)
+}
+
+// AccountPath returns the BIP-44 account path m/44'/195'/account', whose
+// xpub derives the addresses of the account with Derive("0/index")
+func AccountPath(account uint32) string {
+	return fmt.Sprintf("m/44'/%d'/%d'", CoinType, account)
+}
+
+// ParsePath parses a derivation path like m/44'/195'/0'/0/1, a path without
+// the leading m is relative. Hardened indexes end with ' or h
+func ParsePath(path string) (indexes []uint32, absolute bool, err error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if parts[0] == "m" {
+		absolute = true
+		parts = parts[1:]
+	}
+	for _, part := range parts {
+		if part == "" {
+			return nil, false, fmt.Errorf("invalid path %q", path)
+		}
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		i, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(i) >= HardenedOffset {
+			return nil, false, fmt.Errorf("invalid path %q", path)
+		}
+		if hardened {
+			i += uint64(HardenedOffset)
+		}
+		indexes = append(indexes, uint32(i))
+	}
+	return indexes, absolute, nil
+}
+
+// Derive returns the key at path. An absolute path must start from the
+// master key, a relative one like 0/5 starts from k
+func (k *HDKey) Derive(path string) (*HDKey, error) {
+	indexes, absolute, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if absolute && k.depth != 0 {
+		return nil, fmt.Errorf("absolute path %q needs the master key", path)
+	}
+	key := k
+	for _, i := range indexes {
+		key, err = key.Child(i)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// Child returns the child key at index, hardened if index >= HardenedOffset
+func (k *HDKey) Child(index uint32) (*HDKey, error) {
+	if k.depth == 255 {
+		return nil, fmt.Errorf("max depth reached")
+	}
+	hardened := index >= HardenedOffset
+	if hardened && k.privKey == nil {
+		return nil, ErrHardenedFromPublic
+	}
+
+	var data []byte
+	if hardened {
+		data = append([]byte{0}, k.privKey.D.FillBytes(make([]byte, 32))...)
+	} else {
+		data = compressPubKey(k.pubKey)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+
+	curve := ecc.P256k1()
+	n := curve.Params().N
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(n) >= 0 {
+		return nil, ErrInvalidChild
+	}
+
+	child := &HDKey{
+		depth:     k.depth + 1,
+		childNum:  index,
+		chainCode: sum[32:],
+	}
+	copy(child.parentFP[:], k.fingerprint())
+	if k.privKey != nil {
+		d := il.Add(il, k.privKey.D)
+		d.Mod(d, n)
+		if d.Sign() == 0 {
+			return nil, ErrInvalidChild
+		}
+		child.privKey = privKeyFromBytes(d.FillBytes(make([]byte, 32)))
+		child.pubKey = &child.privKey.PublicKey
+		return child, nil
+	}
+
+	x, y := curve.ScalarBaseMult(sum[:32])
+	x, y = curve.Add(x, y, k.pubKey.X, k.pubKey.Y)
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return nil, ErrInvalidChild
+	}
+	child.pubKey = &ecdsa.PublicKey{Curve: curve, X: x, Y: y}
+	return child, nil
+}
+
+// Neuter returns the public extended key of k
+func (k *HDKey) Neuter() *HDKey {
+	pub := *k
+	pub.privKey = nil
+	return &pub
+}
+
+// IsPrivate reports whether k can derive private keys
+func (k *HDKey) IsPrivate() bool {
+	return k.privKey != nil
+}
+
+// Depth returns the number of derivations from the master key
+func (k *HDKey) Depth() uint8 {
+	return k.depth
+}
+
+// Index returns the child index of k
+func (k *HDKey) Index() uint32 {
+	return k.childNum
+}
+
+// PublicKey returns the uncompressed public key
+func (k *HDKey) PublicKey() []byte {
+	return marshalPubKey(k.pubKey)
+}
+
+// Address returns the TRON address of k, for private and public keys
+func (k *HDKey) Address() address.Address {
+	addr, _ := address.FromPublicKey(k.PublicKey())
+	return addr
+}
+
+// Wallet returns the signing wallet of a private key
+func (k *HDKey) Wallet() (*Wallet, error) {
+	if k.privKey == nil {
+		return nil, fmt.Errorf("public key cannot sign")
+	}
+	return &Wallet{privKey: k.privKey, address: k.Address()}, nil
+}
+
+// Addresses returns the watch-only addresses at 0/from to 0/from+count-1
+// of an account key, for example an xpub at m/44'/195'/account'
+func (k *HDKey) Addresses(from, count uint32) ([]address.Address, error) {
+	external, err := k.Child(0)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]address.Address, 0, count)
+	for i := from; i < from+count; i++ {
+		child, err := external.Child(i)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, child.Address())
+	}
+	return addrs, nil
+}
+
+func (k *HDKey) fingerprint() []byte {
+	sha := sha256.Sum256(compressPubKey(k.pubKey))
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)[:4]
+}
+
+// String returns the base58 serialization, xprv for private keys and xpub
+// for public keys
+func (k *HDKey) String() string {
+	version := xpubVersion
+	if k.privKey != nil {
+		version = xprvVersion
+	}
+	data := make([]byte, 0, 82)
+	data = binary.BigEndian.AppendUint32(data, version)
+	data = append(data, k.depth)
+	data = append(data, k.parentFP[:]...)
+	data = binary.BigEndian.AppendUint32(data, k.childNum)
+	data = append(data, k.chainCode...)
+	if k.privKey != nil {
+		data = append(data, 0)
+		data = append(data, k.privKey.D.FillBytes(make([]byte, 32))...)
+	} else {
+		data = append(data, compressPubKey(k.pubKey)...)
+	}
+	data = append(data, doubleSha256(data)[:4]...)
+	return base58.Encode(data, base58.BitcoinAlphabet)
+}
+
+// ParseHDKey parses an xprv or xpub string
+func ParseHDKey(s string) (*HDKey, error) {
+	data, err := base58.Decode(s, base58.BitcoinAlphabet)
+	if err != nil || len(data) != 82 {
+		return nil, ErrInvalidHDKey
+	}
+	if !bytes.Equal(doubleSha256(data[:78])[:4], data[78:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidHDKey)
+	}
+	k := &HDKey{
+		depth:     data[4],
+		childNum:  binary.BigEndian.Uint32(data[9:13]),
+		chainCode: data[13:45],
+	}
+	copy(k.parentFP[:], data[5:9])
+	keyData := data[45:78]
+	switch binary.BigEndian.Uint32(data[:4]) {
+	case xprvVersion:
+		d := new(big.Int).SetBytes(keyData[1:])
+		if keyData[0] != 0 || d.Sign() == 0 || d.Cmp(ecc.P256k1().Params().N) >= 0 {
+			return nil, ErrInvalidHDKey
+		}
+		k.privKey = privKeyFromBytes(keyData[1:])
+		k.pubKey = &k.privKey.PublicKey
+	case xpubVersion:
+		k.pubKey, err = decompressPubKey(keyData)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown version", ErrInvalidHDKey)
+	}
+	return k, nil
+}
+
+func doubleSha256(data []byte) []byte {
+	h0 := sha256.Sum256(data)
+	h1 := sha256.Sum256(h0[:])
+	return h1[:]
+}
+
+func compressPubKey(p *ecdsa.PublicKey) []byte {
+	b := make([]byte, 33)
+	b[0] = 0x02 + byte(p.Y.Bit(0))
+	p.X.FillBytes(b[1:])
+	return b
+}
+
+// decompressPubKey solves y^2 = x^3 + 7, p = 3 mod 4 so y = (x^3+7)^((p+1)/4)
+func decompressPubKey(b []byte) (*ecdsa.PublicKey, error) {
+	if len(b) != 33 || (b[0] != 0x02 && b[0] != 0x03) {
+		return nil, fmt.Errorf("%w: bad public key", ErrInvalidHDKey)
+	}
+	curve := ecc.P256k1()
+	p := curve.Params().P
+	x := new(big.Int).SetBytes(b[1:])
+	if x.Cmp(p) >= 0 {
+		return nil, fmt.Errorf("%w: bad public key", ErrInvalidHDKey)
+	}
+	y2 := new(big.Int).Exp(x, big.NewInt(3), p)
+	y2.Add(y2, big.NewInt(7))
+	y2.Mod(y2, p)
+	exp := new(big.Int).Add(p, big.NewInt(1))
+	exp.Rsh(exp, 2)
+	y := new(big.Int).Exp(y2, exp, p)
+	if new(big.Int).Exp(y, big.NewInt(2), p).Cmp(y2) != 0 {
+		return nil, fmt.Errorf("%w: point not on curve", ErrInvalidHDKey)
+	}
+	if y.Bit(0) != uint(b[0]&1) {
+		y.Sub(p, y)
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
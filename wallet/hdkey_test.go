@@ -0,0 +1,171 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// BIP-39 vectors of the reference implementation, with passphrase TREZOR
+var mnemonicVectors = []struct {
+	entropy  string
+	mnemonic string
+	seed     string
+}{
+	{
+		"00000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+		"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+	},
+	{
+		"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+		"legal winner thank year wave sausage worth useful legal winner thank yellow",
+		"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+	},
+	{
+		"ffffffffffffffffffffffffffffffff",
+		"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong",
+		"ac27495480225222079d7be181583751e86f571027b0497b5b5d11218e0a8a13332572917f0f8e5a589620c6f15b11c61dee327651a14c34e18231052e48c069",
+	},
+	{
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon art",
+		"bda85446c68413707090a52022edd26a1c9462295029f2e60cd7c4f2bbd3097170af7a4d73245cafa9c3cca8d561a7c3de6f5d4a10be8ed2a5e608d68f92fcc8",
+	},
+}
+
+func TestMnemonicVectors(t *testing.T) {
+	for _, v := range mnemonicVectors {
+		entropy, _ := hex.DecodeString(v.entropy)
+		mnemonic, err := MnemonicFromEntropy(entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mnemonic != v.mnemonic {
+			t.Fatalf("mnemonic of %s: %q, want %q", v.entropy, mnemonic, v.mnemonic)
+		}
+		back, err := MnemonicToEntropy(v.mnemonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(back) != v.entropy {
+			t.Fatalf("entropy of %q: %x, want %s", v.mnemonic, back, v.entropy)
+		}
+		seed, err := MnemonicToSeed(v.mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(seed) != v.seed {
+			t.Fatalf("seed of %q: %x, want %s", v.mnemonic, seed, v.seed)
+		}
+	}
+}
+
+func TestMnemonicChecksum(t *testing.T) {
+	err := ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon")
+	if !errors.Is(err, ErrChecksum) {
+		t.Fatalf("bad checksum: got %v, want ErrChecksum", err)
+	}
+	if err := ValidateMnemonic("abandon abandon abandon"); err == nil {
+		t.Fatal("3 words accepted")
+	}
+	if err := ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon tron"); err == nil {
+		t.Fatal("unknown word accepted")
+	}
+}
+
+// BIP-32 test vector 1
+func TestHDKeyVector(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range []struct {
+		path string
+		xprv string
+		xpub string
+	}{
+		{
+			"m",
+			"xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi",
+			"xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8",
+		},
+		{
+			"m/0'",
+			"xprv9uHRZZhk6KAJC1avXpDAp4MDc3sQKNxDiPvvkX8Br5ngLNv1TxvUxt4cV1rGL5hj6KCesnDYUhd7oWgT11eZG7XnxHrnYeSvkzY7d2bhkJ7",
+			"xpub68Gmy5EdvgibQVfPdqkBBCHxA5htiqg55crXYuXoQRKfDBFA1WEjWgP6LHhwBZeNK1VTsfTFUHCdrfp1bgwQ9xv5ski8PX9rL2dZXvgGDnw",
+		},
+		{
+			"m/0'/1",
+			"xprv9wTYmMFdV23N2TdNG573QoEsfRrWKQgWeibmLntzniatZvR9BmLnvSxqu53Kw1UmYPxLgboyZQaXwTCg8MSY3H2EU4pWcQDnRnrVA1xe8fs",
+			"xpub6ASuArnXKPbfEwhqN6e3mwBcDTgzisQN1wXN9BJcM47sSikHjJf3UFHKkNAWbWMiGj7Wf5uMash7SyYq527Hqck2AxYysAA7xmALppuCkwQ",
+		},
+		{
+			"m/0'/1/2'",
+			"xprv9z4pot5VBttmtdRTWfWQmoH1taj2axGVzFqSb8C9xaxKymcFzXBDptWmT7FwuEzG3ryjH4ktypQSAewRiNMjANTtpgP4mLTj34bhnZX7UiM",
+			"xpub6D4BDPcP2GT577Vvch3R8wDkScZWzQzMMUm3PWbmWvVJrZwQY4VUNgqFJPMM3No2dFDFGTsxxpG5uJh7n7epu4trkrX7x7DogT5Uv6fcLW5",
+		},
+	} {
+		k, err := master.Derive(v.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := k.String(); got != v.xprv {
+			t.Fatalf("%s: xprv %s, want %s", v.path, got, v.xprv)
+		}
+		if got := k.Neuter().String(); got != v.xpub {
+			t.Fatalf("%s: xpub %s, want %s", v.path, got, v.xpub)
+		}
+		parsed, err := ParseHDKey(v.xprv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.String() != v.xprv {
+			t.Fatalf("%s: parsed xprv %s", v.path, parsed)
+		}
+	}
+}
+
+func TestHDKeyPublicDerivation(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := NewMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, err := master.Derive(AccountPath(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	xpub, err := ParseHDKey(account.Neuter().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	watched, err := xpub.Addresses(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, addr := range watched {
+		k, err := master.Derive(DerivationPath(0, uint32(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if addr.String() != k.Address().String() {
+			t.Fatalf("address %d: xpub gives %s, private key %s", i, addr, k.Address())
+		}
+	}
+	if _, err := xpub.Child(HardenedOffset); !errors.Is(err, ErrHardenedFromPublic) {
+		t.Fatalf("hardened child of an xpub: %v", err)
+	}
+}
+
+// TronLink derives m/44'/195'/0'/0/0 from a mnemonic without passphrase
+func TestFromMnemonic(t *testing.T) {
+	w, err := FromMnemonic(mnemonicVectors[0].mnemonic, "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Address().String(); got != "TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH" {
+		t.Fatalf("address %s, want TUEZSdKsoDHQMeZwihtdoBiN46zxhGWYdH", got)
+	}
+}
@@ -0,0 +1,291 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fullstackwang/tron-grpc/address"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	KDFScrypt = "scrypt"
+	KDFPBKDF2 = "pbkdf2"
+
+	// StandardScryptN and StandardScryptP are the scrypt settings of
+	// wallet-cli and TronLink
+	StandardScryptN = 1 << 18
+	StandardScryptP = 1
+	// LightScryptN and LightScryptP are faster and weaker, for tests
+	LightScryptN = 1 << 12
+	LightScryptP = 6
+
+	scryptR     = 8
+	scryptDKLen = 32
+	cipherName  = "aes-128-ctr"
+
+	// limits of the kdf parameters of a keystore file, a crafted file must
+	// not make the derivation take minutes or gigabytes of memory
+	maxScryptN          = 1 << 20
+	maxScryptR          = 8
+	maxScryptP          = 16
+	maxPBKDF2Iterations = 10_000_000
+)
+
+var ErrDecrypt = fmt.Errorf("could not decrypt key with given password")
+
+// KeystoreOptions sets the key derivation of an encrypted key, the zero
+// value is scrypt with the standard settings
+type KeystoreOptions struct {
+	KDF string
+	// scrypt settings
+	N int
+	P int
+	// pbkdf2 iteration count
+	Iterations int
+}
+
+// keyJSON is the Web3 Secret Storage v3 format
+type keyJSON struct {
+	Address string      `json:"address"`
+	Crypto  *cryptoJSON `json:"crypto,omitempty"`
+	// some exporters capitalize the crypto section
+	CryptoUpper *cryptoJSON `json:"Crypto,omitempty"`
+	ID          string      `json:"id"`
+	Version     int         `json:"version"`
+}
+
+type cryptoJSON struct {
+	Cipher       string           `json:"cipher"`
+	CipherText   string           `json:"ciphertext"`
+	CipherParams cipherParamsJSON `json:"cipherparams"`
+	KDF          string           `json:"kdf"`
+	KDFParams    kdfParamsJSON    `json:"kdfparams"`
+	MAC          string           `json:"mac"`
+}
+
+type cipherParamsJSON struct {
+	IV string `json:"iv"`
+}
+
+type kdfParamsJSON struct {
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+	// scrypt
+	N int `json:"n,omitempty"`
+	R int `json:"r,omitempty"`
+	P int `json:"p,omitempty"`
+	// pbkdf2
+	C   int    `json:"c,omitempty"`
+	PRF string `json:"prf,omitempty"`
+}
+
+// EncryptKey returns the keystore json of the wallet key encrypted with
+// password, opts may be nil
+func (w *Wallet) EncryptKey(password string, opts *KeystoreOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &KeystoreOptions{}
+	}
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	params := kdfParamsJSON{DKLen: scryptDKLen, Salt: hex.EncodeToString(salt)}
+	kdf := opts.KDF
+	switch kdf {
+	case "", KDFScrypt:
+		kdf = KDFScrypt
+		params.N, params.R, params.P = opts.N, scryptR, opts.P
+		if params.N == 0 {
+			params.N = StandardScryptN
+		}
+		if params.P == 0 {
+			params.P = StandardScryptP
+		}
+	case KDFPBKDF2:
+		params.C, params.PRF = opts.Iterations, "hmac-sha256"
+		if params.C == 0 {
+			params.C = 262144
+		}
+	default:
+		return nil, fmt.Errorf("unknown kdf %s", opts.KDF)
+	}
+	derivedKey, err := deriveKey(kdf, &params, password)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherText, err := aesCTR(derivedKey[:16], iv, w.privKey.D.FillBytes(make([]byte, 32)))
+	if err != nil {
+		return nil, err
+	}
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(&keyJSON{
+		Address: w.address.String(),
+		Crypto: &cryptoJSON{
+			Cipher:       cipherName,
+			CipherText:   hex.EncodeToString(cipherText),
+			CipherParams: cipherParamsJSON{IV: hex.EncodeToString(iv)},
+			KDF:          kdf,
+			KDFParams:    params,
+			MAC:          hex.EncodeToString(keystoreMAC(derivedKey, cipherText)),
+		},
+		ID:      id,
+		Version: 3,
+	})
+}
+
+// DecryptKey decrypts a keystore json, it fails with ErrDecrypt on a wrong
+// password
+func DecryptKey(keyjson []byte, password string) (*Wallet, error) {
+	k := new(keyJSON)
+	if err := json.Unmarshal(keyjson, k); err != nil {
+		return nil, err
+	}
+	if k.Version != 3 {
+		return nil, fmt.Errorf("keystore version %d not supported", k.Version)
+	}
+	c := k.Crypto
+	if c == nil {
+		c = k.CryptoUpper
+	}
+	if c == nil {
+		return nil, fmt.Errorf("keystore has no crypto section")
+	}
+	if c.Cipher != cipherName {
+		return nil, fmt.Errorf("cipher %s not supported", c.Cipher)
+	}
+	cipherText, err := hex.DecodeString(c.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := hex.DecodeString(c.CipherParams.IV)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := hex.DecodeString(c.MAC)
+	if err != nil {
+		return nil, err
+	}
+
+	derivedKey, err := deriveKey(strings.ToLower(c.KDF), &c.KDFParams, password)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(keystoreMAC(derivedKey, cipherText), mac) {
+		return nil, ErrDecrypt
+	}
+	key, err := aesCTR(derivedKey[:16], iv, cipherText)
+	if err != nil {
+		return nil, err
+	}
+	w, err := FromPrivateKey(hex.EncodeToString(key))
+	if err != nil {
+		return nil, err
+	}
+	if k.Address != "" {
+		addr, err := parseKeystoreAddress(k.Address)
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(addr, w.address) {
+			return nil, fmt.Errorf("keystore address %s does not match key address %s", addr, w.address)
+		}
+	}
+	return w, nil
+}
+
+// parseKeystoreAddress accepts base58 as written by wallet-cli, hex with
+// the 41 prefix, or the 20 byte hex of ethereum keystores
+func parseKeystoreAddress(s string) (address.Address, error) {
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	switch len(s) {
+	case address.LengthBase58:
+		return address.FromBase58(s)
+	case address.Length * 2:
+		return address.FromHex(s)
+	case address.LengthEthAddress * 2:
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return nil, err
+		}
+		return address.FromEthAddress(b)
+	}
+	return nil, fmt.Errorf("invalid keystore address %s", s)
+}
+
+func deriveKey(kdf string, params *kdfParamsJSON, password string) ([]byte, error) {
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if params.DKLen < 32 {
+		return nil, fmt.Errorf("dklen %d too short", params.DKLen)
+	}
+	switch kdf {
+	case KDFScrypt:
+		if params.N > maxScryptN || params.R > maxScryptR || params.P > maxScryptP {
+			return nil, fmt.Errorf("scrypt parameters n=%d r=%d p=%d above n=%d r=%d p=%d",
+				params.N, params.R, params.P, maxScryptN, maxScryptR, maxScryptP)
+		}
+		return scrypt.Key([]byte(password), salt, params.N, params.R, params.P, params.DKLen)
+	case KDFPBKDF2:
+		if params.PRF != "hmac-sha256" {
+			return nil, fmt.Errorf("pbkdf2 prf %s not supported", params.PRF)
+		}
+		if params.C < 1 || params.C > maxPBKDF2Iterations {
+			return nil, fmt.Errorf("pbkdf2 iteration count %d not in 1..%d", params.C, maxPBKDF2Iterations)
+		}
+		return pbkdf2.Key([]byte(password), salt, params.C, params.DKLen, sha256.New), nil
+	}
+	return nil, fmt.Errorf("unknown kdf %s", kdf)
+}
+
+func keystoreMAC(derivedKey, cipherText []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(derivedKey[16:32])
+	h.Write(cipherText)
+	return h.Sum(nil)
+}
+
+func aesCTR(key, iv, in []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid iv length %d", len(iv))
+	}
+	out := make([]byte, len(in))
+	cipher.NewCTR(block, iv).XORKeyStream(out, in)
+	return out, nil
+}
+
+// newUUID returns a random version 4 uuid
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
@@ -0,0 +1,195 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fullstackwang/tron-grpc/address"
+)
+
+var ErrAccountNotFound = fmt.Errorf("account not found in keystore")
+
+// KeyStore is a directory of keystore files, one account per file
+type KeyStore struct {
+	dir  string
+	opts *KeystoreOptions
+}
+
+// NewKeyStore returns the keystore of dir, created if needed. opts are used
+// to encrypt new keys and may be nil
+func NewKeyStore(dir string, opts *KeystoreOptions) (*KeyStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &KeyStore{dir: dir, opts: opts}, nil
+}
+
+// Dir returns the directory of the keystore
+func (ks *KeyStore) Dir() string {
+	return ks.dir
+}
+
+type keyFile struct {
+	path    string
+	address address.Address
+}
+
+// files reads the address of every keystore file, files which are not
+// keystores are skipped
+func (ks *KeyStore) files() ([]keyFile, error) {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []keyFile
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || strings.HasSuffix(e.Name(), ".tmp") {
+			continue
+		}
+		path := filepath.Join(ks.dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var k keyJSON
+		if json.Unmarshal(data, &k) != nil || k.Version != 3 {
+			continue
+		}
+		addr, err := parseKeystoreAddress(k.Address)
+		if err != nil {
+			continue
+		}
+		files = append(files, keyFile{path: path, address: addr})
+	}
+	return files, nil
+}
+
+// Accounts returns the addresses of the stored keys
+func (ks *KeyStore) Accounts() ([]address.Address, error) {
+	files, err := ks.files()
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]address.Address, 0, len(files))
+	for _, f := range files {
+		addrs = append(addrs, f.address)
+	}
+	return addrs, nil
+}
+
+// Has reports whether the key of addr is stored
+func (ks *KeyStore) Has(addr address.Address) bool {
+	_, err := ks.Path(addr)
+	return err == nil
+}
+
+// Path returns the keystore file of addr
+func (ks *KeyStore) Path(addr address.Address) (string, error) {
+	files, err := ks.files()
+	if err != nil {
+		return "", err
+	}
+	for _, f := range files {
+		if bytes.Equal(f.address, addr) {
+			return f.path, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrAccountNotFound, addr)
+}
+
+// Unlock decrypts the key of addr into a signing wallet
+func (ks *KeyStore) Unlock(addr address.Address, password string) (*Wallet, error) {
+	path, err := ks.Path(addr)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptKeyFile(path, password)
+}
+
+// Import stores the key of w encrypted with password. It fails when the
+// account is already stored or being imported by another process
+func (ks *KeyStore) Import(w *Wallet, password string) (string, error) {
+	data, err := w.EncryptKey(password, ks.opts)
+	if err != nil {
+		return "", err
+	}
+
+	// the lock file is created exclusively, so only one import of the
+	// account can pass the Has check
+	lock := filepath.Join(ks.dir, "."+w.Address().String()+".lock")
+	f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return "", fmt.Errorf("account %s is being imported, or remove %s", w.Address(), lock)
+		}
+		return "", err
+	}
+	f.Close()
+	defer os.Remove(lock)
+	if ks.Has(w.Address()) {
+		return "", fmt.Errorf("account %s already exists", w.Address())
+	}
+
+	name := fmt.Sprintf("UTC--%s--%s.json", time.Now().UTC().Format("2006-01-02T15-04-05.000000000Z"), w.Address())
+	path := filepath.Join(ks.dir, name)
+	// write to a temp file first so a partial key is never listed, the
+	// link never replaces an existing file
+	tmp := path + ".tmp"
+	f, err = os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp)
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := os.Link(tmp, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// NewAccount generates a key and stores it encrypted with password
+func (ks *KeyStore) NewAccount(password string) (*Wallet, error) {
+	w, err := Generate()
+	if err != nil {
+		return nil, err
+	}
+	_, err = ks.Import(w, password)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Delete removes the key of addr after checking the password
+func (ks *KeyStore) Delete(addr address.Address, password string) error {
+	path, err := ks.Path(addr)
+	if err != nil {
+		return err
+	}
+	if _, err := DecryptKeyFile(path, password); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// DecryptKeyFile decrypts the keystore file at path
+func DecryptKeyFile(path, password string) (*Wallet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptKey(data, password)
+}
@@ -0,0 +1,156 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+)
+
+// the fixtures are the test vectors of the Web3 Secret Storage definition,
+// the scrypt one with the base58 address written by wallet-cli
+const (
+	fixturePassword = "testpassword"
+	fixtureKey      = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+	fixtureAddress  = "TA25SJ2Uo4NQk2SozYKmZ4wQnpq5T7FNBZ"
+)
+
+func TestDecryptKeyFixtures(t *testing.T) {
+	want, err := FromPrivateKey(fixtureKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want.Address().String() != fixtureAddress {
+		t.Fatalf("fixture key address %s, want %s", want.Address(), fixtureAddress)
+	}
+	for _, path := range []string{"testdata/v3_pbkdf2.json", "testdata/v3_scrypt.json"} {
+		w, err := DecryptKeyFile(path, fixturePassword)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if !bytes.Equal(w.Address(), want.Address()) {
+			t.Fatalf("%s: address %s, want %s", path, w.Address(), want.Address())
+		}
+		if _, err := DecryptKeyFile(path, "wrong"); !errors.Is(err, ErrDecrypt) {
+			t.Fatalf("%s: wrong password: got %v, want ErrDecrypt", path, err)
+		}
+	}
+}
+
+// kdf parameters above the limits are refused before any derivation
+func TestDecryptKeyKDFLimits(t *testing.T) {
+	tests := []struct {
+		path  string
+		param string
+		value int
+	}{
+		{"testdata/v3_scrypt.json", "n", 1 << 21},
+		{"testdata/v3_scrypt.json", "r", 9},
+		{"testdata/v3_scrypt.json", "p", 17},
+		{"testdata/v3_pbkdf2.json", "c", 10_000_001},
+		{"testdata/v3_pbkdf2.json", "c", 0},
+	}
+	for _, tt := range tests {
+		data, err := os.ReadFile(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var key map[string]any
+		if err := json.Unmarshal(data, &key); err != nil {
+			t.Fatal(err)
+		}
+		key["crypto"].(map[string]any)["kdfparams"].(map[string]any)[tt.param] = tt.value
+		if data, err = json.Marshal(key); err != nil {
+			t.Fatal(err)
+		}
+		_, err = DecryptKey(data, fixturePassword)
+		if err == nil || errors.Is(err, ErrDecrypt) {
+			t.Errorf("%s %s=%d: got %v, want a parameter error", tt.path, tt.param, tt.value, err)
+		}
+	}
+
+	w, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.EncryptKey("secret", &KeystoreOptions{N: 1 << 21, P: 1}); err == nil {
+		t.Error("encrypted with scrypt n above the limit")
+	}
+}
+
+func TestEncryptKeyRoundTrip(t *testing.T) {
+	w, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opts := range []*KeystoreOptions{
+		{N: LightScryptN, P: LightScryptP},
+		{KDF: KDFPBKDF2, Iterations: 1024},
+	} {
+		data, err := w.EncryptKey("secret", opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := DecryptKey(data, "secret")
+		if err != nil {
+			t.Fatalf("%s: %v", opts.KDF, err)
+		}
+		if !bytes.Equal(got.Address(), w.Address()) {
+			t.Fatalf("%s: address %s, want %s", opts.KDF, got.Address(), w.Address())
+		}
+	}
+}
+
+func TestKeyStoreImportOnce(t *testing.T) {
+	ks, err := NewKeyStore(t.TempDir(), &KeystoreOptions{N: LightScryptN, P: LightScryptP})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const imports = 8
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	stored := 0
+	for i := 0; i < imports; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ks.Import(w, "secret"); err == nil {
+				mu.Lock()
+				stored++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if stored != 1 {
+		t.Fatalf("%d concurrent imports succeeded, want 1", stored)
+	}
+
+	accounts, err := ks.Accounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 || !bytes.Equal(accounts[0], w.Address()) {
+		t.Fatalf("accounts %v, want %s", accounts, w.Address())
+	}
+	entries, err := os.ReadDir(ks.Dir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("%d files left in the keystore, want 1", len(entries))
+	}
+	if _, err := ks.Import(w, "secret"); err == nil {
+		t.Fatal("second import succeeded")
+	}
+	if _, err := ks.Unlock(w.Address(), "secret"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,117 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// wordlist_english.txt is the BIP-39 english word list
+//
+//go:embed wordlist_english.txt
+var englishWords string
+
+var (
+	wordList  = strings.Split(strings.TrimSpace(englishWords), "\n")
+	wordIndex = func() map[string]int {
+		m := make(map[string]int, len(wordList))
+		for i, w := range wordList {
+			m[w] = i
+		}
+		return m
+	}()
+)
+
+var (
+	ErrEntropyLength   = fmt.Errorf("entropy must be 128 to 256 bits and a multiple of 32")
+	ErrInvalidMnemonic = fmt.Errorf("invalid mnemonic")
+	ErrChecksum        = fmt.Errorf("invalid mnemonic checksum")
+)
+
+// NewMnemonic generates a BIP-39 mnemonic from bits of random entropy, 128
+// gives 12 words and 256 gives 24 words
+func NewMnemonic(bits int) (string, error) {
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", ErrEntropyLength
+	}
+	entropy := make([]byte, bits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", err
+	}
+	return MnemonicFromEntropy(entropy)
+}
+
+// MnemonicFromEntropy encodes entropy as a BIP-39 mnemonic
+func MnemonicFromEntropy(entropy []byte) (string, error) {
+	bits := len(entropy) * 8
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", ErrEntropyLength
+	}
+	checksumBits := bits / 32
+	hash := sha256.Sum256(entropy)
+
+	// entropy followed by the first checksumBits of its hash
+	n := new(big.Int).SetBytes(entropy)
+	n.Lsh(n, uint(checksumBits))
+	n.Or(n, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	words := make([]string, (bits+checksumBits)/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = wordList[new(big.Int).And(n, mask).Int64()]
+		n.Rsh(n, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes a BIP-39 mnemonic and verifies its checksum
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, ErrInvalidMnemonic
+	}
+	n := new(big.Int)
+	for _, w := range words {
+		i, ok := wordIndex[w]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown word %q", ErrInvalidMnemonic, w)
+		}
+		n.Lsh(n, 11)
+		n.Or(n, big.NewInt(int64(i)))
+	}
+
+	checksumBits := len(words) * 11 / 33
+	checksum := new(big.Int).And(n, big.NewInt(1<<checksumBits-1)).Int64()
+	n.Rsh(n, uint(checksumBits))
+	entropy := n.FillBytes(make([]byte, checksumBits*4))
+
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum {
+		return nil, ErrChecksum
+	}
+	return entropy, nil
+}
+
+// ValidateMnemonic checks the words and the checksum of a BIP-39 mnemonic
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+// MnemonicToSeed returns the 64 byte BIP-39 seed of a mnemonic, the
+// passphrase may be empty. The mnemonic is validated first
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	password := strings.Join(strings.Fields(norm.NFKD.String(mnemonic)), " ")
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+	return pbkdf2.Key([]byte(password), []byte(salt), 2048, 64, sha512.New), nil
+}
@@ -0,0 +1,194 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/dustinxie/ecc"
+	"github.com/fullstackwang/tron-grpc/abi"
+	"github.com/fullstackwang/tron-grpc/address"
+	"github.com/fullstackwang/tron-grpc/core"
+	"github.com/golang/protobuf/proto"
+	"golang.org/x/crypto/sha3"
+)
+
+// MessageFormat is a way of hashing a message before signing it
+type MessageFormat int
+
+const (
+	// MessageFormatTron is the format of Wallet.SignMessage,
+	// "\x19Tron Signed Message:\n" followed by the length and the message
+	MessageFormatTron MessageFormat = iota
+	// MessageFormatV2 is TronLink signMessageV2, "\x19TRON Signed Message:\n"
+	// followed by the length and the message
+	MessageFormatV2
+	// MessageFormatLegacy is TronLink sign of a hex string, "\x19TRON Signed
+	// Message:\n32" followed by the decoded bytes whatever their length
+	MessageFormatLegacy
+)
+
+var ErrInvalidSignature = fmt.Errorf("invalid signature")
+
+// MessageHash returns the keccak256 hash signed for msg in format
+func MessageHash(msg []byte, format MessageFormat) []byte {
+	var prefix string
+	switch format {
+	case MessageFormatV2:
+		prefix = fmt.Sprintf("\x19TRON Signed Message:\n%d", len(msg))
+	case MessageFormatLegacy:
+		prefix = "\x19TRON Signed Message:\n32"
+	default:
+		prefix = fmt.Sprintf("\x19Tron Signed Message:\n%d", len(msg))
+	}
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(prefix))
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+// TransactionHash returns the sha256 of the raw data, which is the txid and
+// the hash signed by each signature of the transaction
+func TransactionHash(tx *core.Transaction) ([]byte, error) {
+	rawData, err := proto.Marshal(tx.GetRawData())
+	if err != nil {
+		return nil, err
+	}
+	hash := sha256.Sum256(rawData)
+	return hash[:], nil
+}
+
+// RecoverPubKey returns the uncompressed public key which made the 65 byte
+// r||s||v signature of hash, v may be 0/1 or 27/28
+func RecoverPubKey(hash, sig []byte) ([]byte, error) {
+	if len(sig) != 65 {
+		return nil, fmt.Errorf("%w: length %d", ErrInvalidSignature, len(sig))
+	}
+	normalized := append([]byte(nil), sig...)
+	if normalized[64] >= 27 {
+		normalized[64] -= 27
+	}
+	if normalized[64] > 1 {
+		return nil, fmt.Errorf("%w: recovery id %d", ErrInvalidSignature, sig[64])
+	}
+	pub, err := ecc.RecoverEthereum(hash, normalized)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return pub, nil
+}
+
+// RecoverAddress returns the address which made the signature of hash
+func RecoverAddress(hash, sig []byte) (address.Address, error) {
+	pub, err := RecoverPubKey(hash, sig)
+	if err != nil {
+		return nil, err
+	}
+	return address.FromPublicKey(pub)
+}
+
+// RecoverTransactionSigners returns the address of every signature of tx,
+// in the order of the signatures
+func RecoverTransactionSigners(tx *core.Transaction) ([]address.Address, error) {
+	hash, err := TransactionHash(tx)
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]address.Address, 0, len(tx.GetSignature()))
+	for i, sig := range tx.GetSignature() {
+		addr, err := RecoverAddress(hash, sig)
+		if err != nil {
+			return nil, fmt.Errorf("signature %d: %w", i, err)
+		}
+		addrs = append(addrs, addr)
+	}
+	return addrs, nil
+}
+
+// VerifyTransaction reports whether addr made one of the signatures of tx
+func VerifyTransaction(tx *core.Transaction, addr address.Address) bool {
+	signers, err := RecoverTransactionSigners(tx)
+	if err != nil {
+		return false
+	}
+	for _, signer := range signers {
+		if bytes.Equal(signer, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkLowS rejects a signature with s in the upper half of the curve
+// order. TronLink and TronWeb only make low s signatures, the high s twin
+// of one is a malleated copy
+func checkLowS(sig []byte) error {
+	if len(sig) != 65 {
+		return fmt.Errorf("%w: length %d", ErrInvalidSignature, len(sig))
+	}
+	s := new(big.Int).SetBytes(sig[32:64])
+	if s.Cmp(new(big.Int).Rsh(ecc.P256k1().Params().N, 1)) > 0 {
+		return fmt.Errorf("%w: high s", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// RecoverMessageSigner returns the address which signed msg in format. A
+// legacy TronLink message is the hex string given to TronLink, with or
+// without 0x. Signatures with a high s are rejected
+func RecoverMessageSigner(msg string, sig []byte, format MessageFormat) (address.Address, error) {
+	if err := checkLowS(sig); err != nil {
+		return nil, err
+	}
+	data := []byte(msg)
+	if format == MessageFormatLegacy {
+		var err error
+		data, err = hex.DecodeString(strings.TrimPrefix(msg, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("legacy message must be hex: %w", err)
+		}
+	}
+	return RecoverAddress(MessageHash(data, format), sig)
+}
+
+// VerifyMessage reports whether addr signed msg, in any of the message
+// formats. sig may be raw bytes or a hex string with or without 0x, as
+// returned by TronLink
+func VerifyMessage(addr address.Address, msg string, sig []byte) bool {
+	if len(sig) != 65 {
+		decoded, err := hex.DecodeString(strings.TrimPrefix(string(sig), "0x"))
+		if err != nil {
+			return false
+		}
+		sig = decoded
+	}
+	for _, format := range []MessageFormat{MessageFormatTron, MessageFormatV2, MessageFormatLegacy} {
+		signer, err := RecoverMessageSigner(msg, sig, format)
+		if err == nil && bytes.Equal(signer, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecoverTypedDataSigner returns the address which signed the TIP-712 hash
+// of td, signatures with a high s are rejected
+func RecoverTypedDataSigner(td *abi.TypedData, sig []byte) (address.Address, error) {
+	if err := checkLowS(sig); err != nil {
+		return nil, err
+	}
+	hash, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+	return RecoverAddress(hash, sig)
+}
+
+// VerifyTypedData reports whether addr signed td
+func VerifyTypedData(addr address.Address, td *abi.TypedData, sig []byte) bool {
+	signer, err := RecoverTypedDataSigner(td, sig)
+	return err == nil && bytes.Equal(signer, addr)
+}
@@ -0,0 +1,193 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/dustinxie/ecc"
+	"golang.org/x/crypto/sha3"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// highS returns the malleated twin of sig, which recovers the same key
+func highS(sig []byte) []byte {
+	n := ecc.P256k1().Params().N
+	out := append([]byte(nil), sig...)
+	s := new(big.Int).SetBytes(sig[32:64])
+	new(big.Int).Sub(n, s).FillBytes(out[32:64])
+	out[64] ^= 1
+	return out
+}
+
+func TestRecoverPubKey(t *testing.T) {
+	// the ecrecover vector of go-ethereum
+	hash := mustHex(t, "ce0677bb30baa8cf067c88db9811f4333d131bf8bcf12fe7065d211dce971008")
+	sig := mustHex(t, "90f27b8b488db00b00606796d2987f6a5f59ae62ea05effe84fef5b8b0e549984a691139ad57a3f0b906637673aa2f63d1f55cb1a69199d4009eea23ceaddc9301")
+	want := mustHex(t, "04e32df42865e97135acfb65f3bae71bdc86f4d49150ad6a440b6f15878109880a0a2b2667f7e725ceea70c673093bf67663e0312623c8e091b13cf2c0f11ef652")
+
+	for _, v := range []byte{1, 28} {
+		sig[64] = v
+		pub, err := RecoverPubKey(hash, sig)
+		if err != nil {
+			t.Fatalf("v %d: %v", v, err)
+		}
+		if !bytes.Equal(pub, want) {
+			t.Fatalf("v %d: public key %x", v, pub)
+		}
+	}
+	addr, err := RecoverAddress(hash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.String() != "TQhjuHnLWLYweT9L6EEVA9tBCpXXjFcpCb" {
+		t.Fatalf("address %s", addr)
+	}
+
+	// the other recovery id gives another key
+	sig[64] = 27
+	if pub, err := RecoverPubKey(hash, sig); err == nil && bytes.Equal(pub, want) {
+		t.Fatal("v 27 recovered the key of v 28")
+	}
+	for _, v := range []byte{2, 26, 29} {
+		sig[64] = v
+		if _, err := RecoverPubKey(hash, sig); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("v %d: got %v, want ErrInvalidSignature", v, err)
+		}
+	}
+	sig[64] = 28
+	for _, bad := range [][]byte{sig[:64], append(append([]byte(nil), sig...), 0), nil} {
+		if _, err := RecoverPubKey(hash, bad); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("length %d: got %v, want ErrInvalidSignature", len(bad), err)
+		}
+	}
+}
+
+func TestMessageHash(t *testing.T) {
+	keccak := func(s string) []byte {
+		h := sha3.NewLegacyKeccak256()
+		h.Write([]byte(s))
+		return h.Sum(nil)
+	}
+	for _, tc := range []struct {
+		format MessageFormat
+		msg    []byte
+		signed string
+	}{
+		{MessageFormatTron, []byte("hello tron"), "\x19Tron Signed Message:\n10hello tron"},
+		{MessageFormatV2, []byte("hello tron"), "\x19TRON Signed Message:\n10hello tron"},
+		// legacy TronLink always writes 32, whatever the length
+		{MessageFormatLegacy, []byte("Hello world"), "\x19TRON Signed Message:\n32Hello world"},
+	} {
+		if got := MessageHash(tc.msg, tc.format); !bytes.Equal(got, keccak(tc.signed)) {
+			t.Fatalf("format %d: hash %x of %q", tc.format, got, tc.signed)
+		}
+	}
+}
+
+// signatures made once with the key of the keystore fixtures
+const (
+	tronSig   = "f61ee4c713f78b2132eac62765354cdef53dc70e2b28acb3e05185110106121e07a3183f1492a7b90e9b1f27373492bdd7c874731bd86e1d54a11d8d44f1400101"
+	v2Sig     = "def1298610561864ef9ccd001355f8061ad8502d557faaf0a2242e281120835d24115a0a71a392a71d1b490556ed3cd6926e5cb46b4f885b939cddb89ecb3be51b"
+	legacySig = "ccb59c109c34f81951d311ae375165f6fc9de5c3974a839fcf444f74b4b9406e5383164772457df3f0e2ae932d9fcf1250c639f667e6c4d92bfd1e22e384a0851b"
+	legacyMsg = "0x48656c6c6f20776f726c64"
+)
+
+func TestRecoverMessageSigner(t *testing.T) {
+	for _, tc := range []struct {
+		format MessageFormat
+		msg    string
+		sig    string
+	}{
+		{MessageFormatTron, "hello tron", tronSig},
+		{MessageFormatV2, "hello tron", v2Sig},
+		{MessageFormatLegacy, legacyMsg, legacySig},
+	} {
+		sig := mustHex(t, tc.sig)
+		signer, err := RecoverMessageSigner(tc.msg, sig, tc.format)
+		if err != nil {
+			t.Fatalf("format %d: %v", tc.format, err)
+		}
+		if signer.String() != fixtureAddress {
+			t.Fatalf("format %d: signer %s, want %s", tc.format, signer, fixtureAddress)
+		}
+		if _, err := RecoverMessageSigner(tc.msg, highS(sig), tc.format); !errors.Is(err, ErrInvalidSignature) {
+			t.Fatalf("format %d: high s: got %v, want ErrInvalidSignature", tc.format, err)
+		}
+	}
+
+	// the V2 signature is not one of the Tron prefix
+	signer, err := RecoverMessageSigner("hello tron", mustHex(t, v2Sig), MessageFormatTron)
+	if err == nil && signer.String() == fixtureAddress {
+		t.Fatal("V2 signature verified with the Tron prefix")
+	}
+	if _, err := RecoverMessageSigner("not hex", mustHex(t, legacySig), MessageFormatLegacy); err == nil {
+		t.Fatal("legacy message not in hex accepted")
+	}
+}
+
+func TestVerifyMessage(t *testing.T) {
+	w, err := FromPrivateKey(fixtureKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		msg string
+		sig string
+	}{
+		{"hello tron", tronSig},
+		{"hello tron", v2Sig},
+		{legacyMsg, legacySig},
+	} {
+		raw := mustHex(t, tc.sig)
+		if !VerifyMessage(w.Address(), tc.msg, raw) {
+			t.Fatalf("%s: raw signature not verified", tc.sig)
+		}
+		// TronLink returns the signature as a hex string
+		if !VerifyMessage(w.Address(), tc.msg, []byte("0x"+tc.sig)) || !VerifyMessage(w.Address(), tc.msg, []byte(tc.sig)) {
+			t.Fatalf("%s: hex signature not verified", tc.sig)
+		}
+		if VerifyMessage(other.Address(), tc.msg, raw) {
+			t.Fatalf("%s: verified for another address", tc.sig)
+		}
+		if VerifyMessage(w.Address(), tc.msg+".", raw) {
+			t.Fatalf("%s: verified for another message", tc.sig)
+		}
+		if VerifyMessage(w.Address(), tc.msg, highS(raw)) {
+			t.Fatalf("%s: high s verified", tc.sig)
+		}
+		if VerifyMessage(w.Address(), tc.msg, raw[:64]) || VerifyMessage(w.Address(), tc.msg, []byte("0x"+tc.sig[:128])) {
+			t.Fatalf("%s: 64 byte signature verified", tc.sig)
+		}
+	}
+
+	// fresh signatures of both v conventions
+	sig, err := w.SignMessage("fresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig[64] > 1 || !VerifyMessage(w.Address(), "fresh", sig) {
+		t.Fatalf("SignMessage signature %x", sig)
+	}
+	sig, err = w.SignMessageV2("fresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig[64] < 27 || !VerifyMessage(w.Address(), "fresh", sig) {
+		t.Fatalf("SignMessageV2 signature %x", sig)
+	}
+}
@@ -2,10 +2,12 @@ package wallet
 
 import (
 	"crypto/ecdsa"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"github.com/dustinxie/ecc"
+	"github.com/fullstackwang/tron-grpc/abi"
 	"github.com/fullstackwang/tron-grpc/address"
 	"github.com/fullstackwang/tron-grpc/core"
 	"github.com/golang/protobuf/proto"
@@ -23,10 +25,15 @@ func (w *Wallet) Address() address.Address {
 }
 
 func (w *Wallet) PublicKey() []byte {
-	pubBytes := make([]byte, 0, 65)
-	pubBytes = append(pubBytes, 0x04) // uncompressed
-	pubBytes = append(pubBytes, w.privKey.X.Bytes()...)
-	pubBytes = append(pubBytes, w.privKey.Y.Bytes()...)
+	return marshalPubKey(&w.privKey.PublicKey)
+}
+
+// marshalPubKey returns the uncompressed public key, X and Y padded to 32 bytes
+func marshalPubKey(p *ecdsa.PublicKey) []byte {
+	pubBytes := make([]byte, 65)
+	pubBytes[0] = 0x04 // uncompressed
+	p.X.FillBytes(pubBytes[1:33])
+	p.Y.FillBytes(pubBytes[33:])
 	return pubBytes
 }
 
@@ -47,15 +54,20 @@ func FromPrivateKey(privateKey string) (*Wallet, error) {
 	}, nil
 }
 
-func genAddressFromPrivKey(p *ecdsa.PrivateKey) address.Address {
-	pubBytes := make([]byte, 0, 64)
-	pubBytes = append(pubBytes, p.X.Bytes()...)
-	pubBytes = append(pubBytes, p.Y.Bytes()...)
+// Generate creates a wallet with a random key
+func Generate() (*Wallet, error) {
+	p, err := ecdsa.GenerateKey(ecc.P256k1(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &Wallet{
+		privKey: p,
+		address: genAddressFromPrivKey(p),
+	}, nil
+}
 
-	s := sha3.NewLegacyKeccak256()
-	s.Write(pubBytes)
-	hash := s.Sum(nil)
-	addr, _ := address.FromEthAddress(hash[12:])
+func genAddressFromPrivKey(p *ecdsa.PrivateKey) address.Address {
+	addr, _ := address.FromPublicKey(marshalPubKey(&p.PublicKey))
 	return addr
 }
 
@@ -87,3 +99,33 @@ func (w *Wallet) SignMessage(msg string) ([]byte, error) {
 	hash := h.Sum(nil)
 	return ecc.SignEthereum(hash, w.privKey)
 }
+
+// SignMessageV2 signs msg like TronLink signMessageV2, v is 27 or 28
+func (w *Wallet) SignMessageV2(msg string) ([]byte, error) {
+	sig, err := ecc.SignEthereum(MessageHash([]byte(msg), MessageFormatV2), w.privKey)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// SignTypedData signs the TIP-712 hash of td, v is 27 or 28 like TronWeb
+// _signTypedData
+func (w *Wallet) SignTypedData(td *abi.TypedData) ([]byte, error) {
+	hash, err := td.Hash()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := ecc.SignEthereum(hash, w.privKey)
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// SignHash signs a 32 byte hash as is, v is 0 or 1
+func (w *Wallet) SignHash(hash []byte) ([]byte, error) {
+	return ecc.SignEthereum(hash, w.privKey)
+}